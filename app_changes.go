@@ -0,0 +1,110 @@
+package lifecycle
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// fileState is the metadata of a file in the app directory that is compared between snapshots.
+type fileState struct {
+	mode    os.FileMode
+	size    int64
+	modTime time.Time
+	link    string
+}
+
+// appSnapshot maps paths relative to the app directory to their metadata.
+type appSnapshot map[string]fileState
+
+// snapshotAppDir records the metadata of each file in appDir, skipping layersDir if it is nested in appDir.
+func snapshotAppDir(appDir, layersDir string) (appSnapshot, error) {
+	snapshot := appSnapshot{}
+	err := filepath.Walk(appDir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == appDir {
+			return nil
+		}
+		if fi.IsDir() && path == layersDir {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(appDir, path)
+		if err != nil {
+			return err
+		}
+		state := fileState{mode: fi.Mode(), size: fi.Size(), modTime: fi.ModTime()}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			if state.link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		snapshot[filepath.ToSlash(rel)] = state
+		return nil
+	})
+	return snapshot, err
+}
+
+// changes returns the paths that were added, removed or modified between s and after.
+// Directories are only reported when they are added or removed.
+func (s appSnapshot) changes(after appSnapshot) (added, removed, modified []string) {
+	for path, state := range after {
+		before, ok := s[path]
+		switch {
+		case !ok:
+			added = append(added, path)
+		case state.mode.IsDir() && before.mode.IsDir():
+		case state != before:
+			modified = append(modified, path)
+		}
+	}
+	for path := range s {
+		if _, ok := after[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(modified)
+	return added, removed, modified
+}
+
+// protectedPaths returns the changed paths that match one of globs, or that are inside a directory that matches one of globs.
+func protectedPaths(globs []string, changes platform.BuildpackAppChanges) ([]string, error) {
+	var out []string
+	for _, paths := range [][]string{changes.Added, changes.Removed, changes.Modified} {
+		for _, path := range paths {
+			protected, err := matchesAny(globs, path)
+			if err != nil {
+				return nil, err
+			}
+			if protected {
+				out = append(out, path)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func matchesAny(globs []string, path string) (bool, error) {
+	for _, glob := range globs {
+		for p := path; p != "."; p = filepath.ToSlash(filepath.Dir(p)) {
+			match, err := filepath.Match(strings.TrimSuffix(filepath.ToSlash(glob), "/"), p)
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to check if '%s' matches '%s'", path, glob)
+			}
+			if match {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
@@ -149,6 +149,18 @@ func testExtract(t *testing.T, when spec.G, it spec.S) {
 			h.AssertError(t, archive.Extract(tr), "failed to create directory")
 		})
 
+		it("fails for hard links", func() {
+			ftr.pushHeader(&tar.Header{
+				Name:     "root/hardlink",
+				Typeflag: tar.TypeLink,
+				Linkname: filepath.FromSlash("../../etc/passwd"),
+				Mode:     int64(0644),
+			})
+
+			h.AssertError(t, archive.Extract(tr), "unknown file type in tar")
+			h.AssertPathDoesNotExist(t, filepath.Join(tmpDir, "root", "hardlink"))
+		})
+
 		it("doesn't alter permissions of existing folders", func() {
 			h.AssertNil(t, os.Mkdir(filepath.Join(tmpDir, "root"), 0744))
 			// Update permissions in case umask was applied.
@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/launch"
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// BuildCheckpoint records the results of the buildpacks that completed a build, in order,
+// so that a failed build can be resumed from the first buildpack that did not complete.
+type BuildCheckpoint struct {
+	PlanHash   string                `toml:"plan-hash"`
+	AppHash    string                `toml:"app-hash"` // the app directory after the last buildpack that completed
+	Buildpacks []CheckpointBuildpack `toml:"buildpacks"`
+}
+
+type CheckpointBuildpack struct {
+	ID         string                `toml:"id"`
+	Version    string                `toml:"version"`
+	LayersHash string                `toml:"layers-hash"`
+	Result     buildpack.BuildResult `toml:"result"`
+}
+
+// ReadBuildCheckpoint reads the checkpoint at path, returning an empty checkpoint if it does not exist.
+func ReadBuildCheckpoint(path string) (*BuildCheckpoint, error) {
+	checkpoint := &BuildCheckpoint{}
+	if _, err := toml.DecodeFile(path, checkpoint); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+// resumable returns true if the previous build was for the same plan,
+// and the app directory has not changed since the last buildpack that completed it.
+func (c *BuildCheckpoint) resumable(planHash, appDir, layersDir string) bool {
+	if c.PlanHash != planHash || len(c.Buildpacks) == 0 {
+		return false
+	}
+	appHash, err := appDirHash(appDir, layersDir)
+	return err == nil && appHash == c.AppHash
+}
+
+// completed returns the recorded result of the i-th buildpack if it completed the previous build,
+// and its layers directory has not changed since.
+func (c *BuildCheckpoint) completed(i int, bp buildpack.GroupBuildpack, layersDir string) (buildpack.BuildResult, bool) {
+	if i >= len(c.Buildpacks) {
+		return buildpack.BuildResult{}, false
+	}
+	recorded := c.Buildpacks[i]
+	if recorded.ID != bp.ID || recorded.Version != bp.Version {
+		return buildpack.BuildResult{}, false
+	}
+	layersHash, err := bpLayersHash(layersDir, bp.ID)
+	if err != nil || layersHash != recorded.LayersHash {
+		return buildpack.BuildResult{}, false
+	}
+	return recorded.Result, true
+}
+
+func (c *BuildCheckpoint) record(bp buildpack.GroupBuildpack, appDir, layersDir string, br buildpack.BuildResult) error {
+	layersHash, err := bpLayersHash(layersDir, bp.ID)
+	if err != nil {
+		return err
+	}
+	if c.AppHash, err = appDirHash(appDir, layersDir); err != nil {
+		return err
+	}
+	c.Buildpacks = append(c.Buildpacks, CheckpointBuildpack{
+		ID:         bp.ID,
+		Version:    bp.Version,
+		LayersHash: layersHash,
+		Result:     br,
+	})
+	return nil
+}
+
+// bpLayersHash hashes the metadata of the files in the layers directory of a buildpack,
+// which is missing if the buildpack created no layers.
+func bpLayersHash(layersDir, bpID string) (string, error) {
+	bpLayersDir := filepath.Join(layersDir, launch.EscapeID(bpID))
+	if _, err := os.Lstat(bpLayersDir); err != nil {
+		if os.IsNotExist(err) {
+			return snapshotHash(appSnapshot{}), nil
+		}
+		return "", err
+	}
+	snapshot, err := snapshotAppDir(bpLayersDir, "")
+	if err != nil {
+		return "", err
+	}
+	return snapshotHash(snapshot), nil
+}
+
+// appDirHash hashes the metadata of the files in the app directory, as recorded by snapshotAppDir.
+func appDirHash(appDir, layersDir string) (string, error) {
+	snapshot, err := snapshotAppDir(appDir, layersDir)
+	if err != nil {
+		return "", err
+	}
+	return snapshotHash(snapshot), nil
+}
+
+// snapshotHash hashes the metadata of the files in snapshot, so that files are not read to detect changes.
+func snapshotHash(snapshot appSnapshot) string {
+	paths := make([]string, 0, len(snapshot))
+	for path := range snapshot {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	h := sha256.New()
+	for _, path := range paths {
+		state := snapshot[path]
+		fmt.Fprintf(h, "%q %o %d %d %q\n", path, uint32(state.mode), state.size, state.modTime.UnixNano(), state.link)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func buildPlanHash(plan platform.BuildPlan) (string, error) {
+	h := sha256.New()
+	if err := toml.NewEncoder(h).Encode(plan); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
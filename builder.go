@@ -6,6 +6,10 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/buildpack"
@@ -39,11 +43,21 @@ type Builder struct {
 	PlatformDir    string
 	Platform       Platform
 	PlatformAPI    *api.Version // TODO: derive from platform
+	BuildTimeout   time.Duration
+	Offline        bool
+	Strict         bool
+	LogPrefix      buildpack.LogPrefix
 	Group          buildpack.Group
 	Plan           platform.BuildPlan
 	Out, Err       io.Writer
 	Logger         Logger
 	BuildpackStore BuildpackStore
+	UsageReport    *platform.UsageReport // optional, records the resources used and app changes made by each bin/build when set
+	// ProtectedAppPaths are globs, relative to the app directory, of paths that buildpacks must not change.
+	ProtectedAppPaths []string
+	// CheckpointPath is optional. When set, the results of each buildpack are checkpointed to it until the build succeeds,
+	// and buildpacks that completed a previous build are skipped if their layers and the app directory are unchanged.
+	CheckpointPath string
 }
 
 func (b *Builder) Build() (*platform.BuildMetadata, error) {
@@ -59,25 +73,65 @@ func (b *Builder) Build() (*platform.BuildMetadata, error) {
 	var bom []buildpack.BOMEntry
 	var slices []layers.Slice
 	var labels []buildpack.Label
+	unmetBy := map[string][]buildpack.GroupBuildpack{}
+
+	previous, checkpoint, err := b.readCheckpoint(config)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, bp := range b.Group.Group {
-		b.Logger.Debugf("Running build for buildpack %s", bp)
+	for i, bp := range b.Group.Group {
+		var br buildpack.BuildResult
+		if result, ok := previous.completed(i, bp, config.LayersDir); ok {
+			b.Logger.Infof("Skipping buildpack %s, completed by previous build", bp)
+			br = result
+		} else {
+			previous = &BuildCheckpoint{}
+			b.Logger.Debugf("Running build for buildpack %s", bp)
 
-		b.Logger.Debug("Looking up buildpack")
-		bpTOML, err := b.BuildpackStore.Lookup(bp.ID, bp.Version)
-		if err != nil {
-			return nil, err
-		}
+			b.Logger.Debug("Looking up buildpack")
+			bpTOML, err := b.BuildpackStore.Lookup(bp.ID, bp.Version)
+			if err != nil {
+				return nil, err
+			}
 
-		b.Logger.Debug("Finding plan")
-		bpPlan := plan.Find(bp.ID)
+			b.Logger.Debug("Finding plan")
+			bpPlan := plan.Find(bp.ID)
 
-		b.Logger.Debug("Getting build environment")
-		bpEnv := env.NewBuildEnv(os.Environ(), b.Platform, bpTOML)
+			b.Logger.Debug("Getting build environment")
+			bpEnv := env.NewBuildEnv(os.Environ(), b.Platform, bpTOML)
 
-		br, err := bpTOML.Build(bpPlan, config, bpEnv)
-		if err != nil {
-			return nil, err
+			var snapshot appSnapshot
+			if b.tracksAppChanges() {
+				if snapshot, err = snapshotAppDir(config.AppDir, config.LayersDir); err != nil {
+					return nil, errors.Wrap(err, "snapshotting app directory")
+				}
+			}
+
+			bpConfig := config
+			bpConfig.Logger = withAttribution(config.Logger, "buildpack", bp.String())
+			var flushOut, flushErr func()
+			bpConfig.Out, flushOut = writerWithAttribution(config.Out, "buildpack", bp.String())
+			bpConfig.Err, flushErr = writerWithAttribution(config.Err, "buildpack", bp.String())
+
+			start := time.Now()
+			br, err = bpTOML.Build(bpPlan, bpConfig, bpEnv)
+			flushOut()
+			flushErr()
+			if b.UsageReport != nil {
+				b.UsageReport.Build = append(b.UsageReport.Build, newBuildpackUsage(bp, time.Since(start), br.Usage))
+			}
+			if err != nil {
+				return nil, err
+			}
+			if b.tracksAppChanges() {
+				if err := b.checkAppChanges(bp, snapshot, config); err != nil {
+					return nil, err
+				}
+			}
+			for _, m := range bpEnv.History() {
+				b.Logger.Debugf("Environment modified: %s", m)
+			}
 		}
 
 		b.Logger.Debug("Updating buildpack processes")
@@ -86,6 +140,9 @@ func (b *Builder) Build() (*platform.BuildMetadata, error) {
 		bom = append(bom, br.BOM...)
 		labels = append(labels, br.Labels...)
 		plan = plan.Filter(br.MetRequires)
+		for _, name := range br.Unmet {
+			unmetBy[name] = append(unmetBy[name], bp.NoOpt().NoAPI().NoHomepage())
+		}
 
 		b.Logger.Debug("Updating process list")
 		warning := processMap.add(br.Processes)
@@ -95,6 +152,10 @@ func (b *Builder) Build() (*platform.BuildMetadata, error) {
 
 		slices = append(slices, br.Slices...)
 
+		if err := b.writeCheckpoint(checkpoint, bp, config, br); err != nil {
+			return nil, err
+		}
+
 		b.Logger.Debugf("Finished running build for buildpack %s", bp)
 	}
 
@@ -105,9 +166,18 @@ func (b *Builder) Build() (*platform.BuildMetadata, error) {
 		}
 	}
 
+	unmet := unmetRequires(plan, unmetBy)
+	for _, u := range unmet {
+		b.Logger.Warn(describeUnmetRequire(u))
+	}
+
 	b.Logger.Debug("Listing processes")
 	procList := processMap.list()
 
+	if err := b.removeCheckpoint(); err != nil {
+		return nil, err
+	}
+
 	b.Logger.Debug("Finished build")
 	return &platform.BuildMetadata{
 		BOM:                         bom,
@@ -116,9 +186,133 @@ func (b *Builder) Build() (*platform.BuildMetadata, error) {
 		Processes:                   procList,
 		Slices:                      slices,
 		BuildpackDefaultProcessType: processMap.defaultType,
+		UnmetRequires:               unmet,
 	}, nil
 }
 
+// unmetRequires returns the requirements in plan, which is left after all buildpacks ran,
+// with the buildpacks that declared each of them unmet.
+func unmetRequires(plan platform.BuildPlan, unmetBy map[string][]buildpack.GroupBuildpack) []platform.UnmetRequire {
+	var out []platform.UnmetRequire
+	for _, entry := range plan.Entries {
+		if len(entry.Requires) == 0 {
+			continue
+		}
+		name := entry.Requires[0].Name // requires in an entry share the name of the provide
+		out = append(out, platform.UnmetRequire{
+			Name:      name,
+			Providers: entry.NoOpt().Providers,
+			UnmetBy:   unmetBy[name],
+		})
+	}
+	return out
+}
+
+func describeUnmetRequire(u platform.UnmetRequire) string {
+	msg := fmt.Sprintf("Build plan requirement '%s' was not met by any buildpack", u.Name)
+	if len(u.UnmetBy) == 0 {
+		return msg
+	}
+	var ids []string
+	for _, bp := range u.UnmetBy {
+		ids = append(ids, bp.String())
+	}
+	return fmt.Sprintf("%s, declared unmet by %s", msg, strings.Join(ids, ", "))
+}
+
+// readCheckpoint returns the checkpoint of the previous build, which is empty if the build cannot be resumed from it,
+// and a new checkpoint for this build.
+func (b *Builder) readCheckpoint(config buildpack.BuildConfig) (previous, checkpoint *BuildCheckpoint, err error) {
+	if b.CheckpointPath == "" {
+		return &BuildCheckpoint{}, nil, nil
+	}
+	planHash, err := buildPlanHash(b.Plan)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "hashing build plan")
+	}
+	if previous, err = ReadBuildCheckpoint(b.CheckpointPath); err != nil {
+		b.Logger.Warnf("Ignoring build checkpoint: %s", err)
+		previous = &BuildCheckpoint{}
+	}
+	if !previous.resumable(planHash, config.AppDir, config.LayersDir) {
+		previous = &BuildCheckpoint{}
+	}
+	return previous, &BuildCheckpoint{PlanHash: planHash}, nil
+}
+
+func (b *Builder) writeCheckpoint(checkpoint *BuildCheckpoint, bp buildpack.GroupBuildpack, config buildpack.BuildConfig, br buildpack.BuildResult) error {
+	if checkpoint == nil {
+		return nil
+	}
+	if err := checkpoint.record(bp, config.AppDir, config.LayersDir, br); err != nil {
+		return errors.Wrapf(err, "checkpointing buildpack %s", bp)
+	}
+	return errors.Wrap(WriteTOML(b.CheckpointPath, checkpoint), "writing build checkpoint")
+}
+
+// removeCheckpoint removes the checkpoint once the build succeeded, so that the next build runs every buildpack.
+func (b *Builder) removeCheckpoint() error {
+	if b.CheckpointPath == "" {
+		return nil
+	}
+	if err := os.Remove(b.CheckpointPath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing build checkpoint")
+	}
+	return nil
+}
+
+// tracksAppChanges returns true if the changes buildpacks make to the app directory are reported or checked,
+// which requires snapshotting the app directory before and after each buildpack.
+func (b *Builder) tracksAppChanges() bool {
+	return b.UsageReport != nil || len(b.ProtectedAppPaths) > 0
+}
+
+// checkAppChanges reports the changes made to the app directory by bp since before was taken,
+// and fails if any of them match ProtectedAppPaths.
+func (b *Builder) checkAppChanges(bp buildpack.GroupBuildpack, before appSnapshot, config buildpack.BuildConfig) error {
+	after, err := snapshotAppDir(config.AppDir, config.LayersDir)
+	if err != nil {
+		return errors.Wrap(err, "snapshotting app directory")
+	}
+	changes := platform.BuildpackAppChanges{ID: bp.ID, Version: bp.Version}
+	changes.Added, changes.Removed, changes.Modified = before.changes(after)
+	for _, path := range changes.Added {
+		b.Logger.Debugf("Buildpack %s added app path: %s", bp, path)
+	}
+	for _, path := range changes.Removed {
+		b.Logger.Debugf("Buildpack %s removed app path: %s", bp, path)
+	}
+	for _, path := range changes.Modified {
+		b.Logger.Debugf("Buildpack %s modified app path: %s", bp, path)
+	}
+	if b.UsageReport != nil {
+		b.UsageReport.AppChanges = append(b.UsageReport.AppChanges, changes)
+	}
+
+	protected, err := protectedPaths(b.ProtectedAppPaths, changes)
+	if err != nil {
+		return err
+	}
+	if len(protected) > 0 {
+		return buildpack.NewLifecycleError(
+			errors.Errorf("buildpack %s changed protected paths in the app directory: %s", bp, strings.Join(protected, ", ")),
+			buildpack.ErrTypeBuildpack,
+		)
+	}
+	return nil
+}
+
+func newBuildpackUsage(bp buildpack.GroupBuildpack, wallTime time.Duration, usage buildpack.Usage) platform.BuildpackUsage {
+	return platform.BuildpackUsage{
+		ID:         bp.ID,
+		Version:    bp.Version,
+		WallTimeMS: wallTime.Milliseconds(),
+		UserTimeMS: usage.UserTime.Milliseconds(),
+		SysTimeMS:  usage.SysTime.Milliseconds(),
+		MaxRSSKB:   usage.MaxRSS,
+	}
+}
+
 // we set default = true for web processes when platformAPI >= 0.6 and buildpackAPI < 0.6
 func updateDefaultProcesses(processes []launch.Process, buildpackAPI *api.Version, platformAPI *api.Version) {
 	if platformAPI.Compare(api.MustParse("0.6")) < 0 || buildpackAPI.Compare(api.MustParse("0.6")) >= 0 {
@@ -150,6 +344,10 @@ func (b *Builder) BuildConfig() (buildpack.BuildConfig, error) {
 		AppDir:      appDir,
 		PlatformDir: platformDir,
 		LayersDir:   layersDir,
+		Timeout:     b.BuildTimeout,
+		Offline:     b.Offline,
+		Strict:      b.Strict,
+		LogPrefix:   b.LogPrefix,
 		Out:         b.Out,
 		Err:         b.Err,
 		Logger:      b.Logger,
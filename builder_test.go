@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
@@ -155,6 +156,55 @@ func testBuilder(t *testing.T, when spec.G, it spec.S) {
 				}
 			})
 
+			it("should report the requirements left unmet by all buildpacks", func() {
+				builder.Plan = platform.BuildPlan{
+					Entries: []platform.BuildPlanEntry{
+						{
+							Providers: []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}},
+							Requires:  []buildpack.Require{{Name: "some-dep", Version: "v1"}},
+						},
+						{
+							Providers: []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}, {ID: "B", Version: "v2"}},
+							Requires:  []buildpack.Require{{Name: "some-unmet-dep", Version: "v2"}},
+						},
+						{
+							Providers: []buildpack.GroupBuildpack{{ID: "B", Version: "v2"}},
+							Requires:  []buildpack.Require{{Name: "some-ignored-dep", Version: "v3"}},
+						},
+					},
+				}
+				bpA := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().SupportsAssetPackages().Return(true)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{
+					MetRequires: []string{"some-dep"},
+					Unmet:       []string{"some-unmet-dep"},
+				}, nil)
+				bpB := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().SupportsAssetPackages().Return(true)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{
+					Unmet: []string{"some-unmet-dep"},
+				}, nil)
+
+				metadata, err := builder.Build()
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, metadata.UnmetRequires, []platform.UnmetRequire{
+					{
+						Name:      "some-unmet-dep",
+						Providers: []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}, {ID: "B", Version: "v2"}},
+						UnmetBy:   []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}, {ID: "B", Version: "v2"}},
+					},
+					{
+						Name:      "some-ignored-dep",
+						Providers: []buildpack.GroupBuildpack{{ID: "B", Version: "v2"}},
+					},
+				})
+				assertLogEntry(t, logHandler, "Build plan requirement 'some-unmet-dep' was not met by any buildpack, declared unmet by A@v1, B@v2")
+				assertLogEntry(t, logHandler, "Build plan requirement 'some-ignored-dep' was not met by any buildpack")
+			})
+
 			when("build metadata", func() {
 				when("bom", func() {
 					it("should aggregate BOM from each buildpack", func() {
@@ -707,6 +757,239 @@ func testBuilder(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("a usage report is provided", func() {
+			it("should record the resources used by each buildpack", func() {
+				builder.UsageReport = &platform.UsageReport{}
+				bpA := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().SupportsAssetPackages().Return(true)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{
+					Usage: buildpack.Usage{UserTime: 1500 * time.Millisecond, SysTime: 250 * time.Millisecond, MaxRSS: 2048},
+				}, nil)
+				bpB := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().SupportsAssetPackages().Return(true)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{
+					Usage: buildpack.Usage{UserTime: 10 * time.Millisecond},
+				}, errors.New("some error"))
+
+				if _, err := builder.Build(); err == nil {
+					t.Fatal("Expected error.\n")
+				}
+				h.AssertEq(t, len(builder.UsageReport.Build), 2)
+				usageA := builder.UsageReport.Build[0]
+				h.AssertEq(t, usageA.ID, "A")
+				h.AssertEq(t, usageA.Version, "v1")
+				h.AssertEq(t, usageA.UserTimeMS, int64(1500))
+				h.AssertEq(t, usageA.SysTimeMS, int64(250))
+				h.AssertEq(t, usageA.MaxRSSKB, int64(2048))
+				h.AssertEq(t, builder.UsageReport.Build[1].ID, "B")
+				h.AssertEq(t, builder.UsageReport.Build[1].UserTimeMS, int64(10))
+			})
+		})
+
+		when("the logger supports attribution", func() {
+			it("should attribute the records of each buildpack to it", func() {
+				builder.Logger = &attributingLogger{Logger: &log.Logger{Handler: logHandler}}
+				for _, bp := range builder.Group.Group {
+					mockBp := testmock.NewMockBuildpack(mockCtrl)
+					buildpackStore.EXPECT().Lookup(bp.ID, bp.Version).Return(mockBp, nil)
+					mockBp.EXPECT().SupportsAssetPackages().Return(true)
+					mockBp.EXPECT().Build(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+						func(_ buildpack.Plan, bpConfig buildpack.BuildConfig, _ buildpack.BuildEnv) (buildpack.BuildResult, error) {
+							bpConfig.Logger.Info("some message")
+							return buildpack.BuildResult{}, nil
+						})
+				}
+
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+
+				assertLogEntry(t, logHandler, "buildpack=A@v1: some message")
+				assertLogEntry(t, logHandler, "buildpack=B@v2: some message")
+			})
+		})
+
+		when("buildpacks change the app directory", func() {
+			var bpA, bpB *testmock.MockBuildpack
+
+			it.Before(func() {
+				h.Mkdir(t, filepath.Join(appDir, "src"))
+				h.Mkfile(t, "some-source", filepath.Join(appDir, "src", "main.go"), filepath.Join(appDir, "README.md"))
+
+				bpA = testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().SupportsAssetPackages().Return(true)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).DoAndReturn(
+					func(_ buildpack.Plan, _ buildpack.BuildConfig, _ buildpack.BuildEnv) (buildpack.BuildResult, error) {
+						h.Mkfile(t, "some-output", filepath.Join(appDir, "generated.txt"))
+						h.AssertNil(t, os.Remove(filepath.Join(appDir, "README.md")))
+						return buildpack.BuildResult{}, nil
+					})
+				bpB = testmock.NewMockBuildpack(mockCtrl)
+				bpB.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).DoAndReturn(
+					func(_ buildpack.Plan, _ buildpack.BuildConfig, _ buildpack.BuildEnv) (buildpack.BuildResult, error) {
+						h.Mkfile(t, "some-other-source-with-a-different-size", filepath.Join(appDir, "src", "main.go"))
+						return buildpack.BuildResult{}, nil
+					})
+			})
+
+			it("should record the changes made by each buildpack", func() {
+				builder.UsageReport = &platform.UsageReport{}
+
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, builder.UsageReport.AppChanges, []platform.BuildpackAppChanges{
+					{ID: "A", Version: "v1", Added: []string{"generated.txt"}, Removed: []string{"README.md"}},
+					{ID: "B", Version: "v2", Modified: []string{"src/main.go"}},
+				})
+			})
+
+			it("should not snapshot the app directory if the changes are neither reported nor protected", func() {
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+
+				if s := h.AllLogs(logHandler); strings.Contains(s, "app path") {
+					t.Fatalf("Unexpected app changes in log:\n%s\n", s)
+				}
+			})
+
+			when("the changes are protected", func() {
+				it("should fail the build", func() {
+					builder.ProtectedAppPaths = []string{"src"}
+
+					_, err := builder.Build()
+					if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeBuildpack {
+						t.Fatalf("Unexpected error:\n%s\n", err)
+					}
+					h.AssertStringContains(t, err.Error(), "buildpack B@v2 changed protected paths in the app directory: src/main.go")
+				})
+			})
+		})
+
+		when("resuming from a checkpoint", func() {
+			var bpA, bpB *testmock.MockBuildpack
+
+			it.Before(func() {
+				builder.CheckpointPath = filepath.Join(layersDir, "build-checkpoint.toml")
+				builder.Plan = platform.BuildPlan{Entries: []platform.BuildPlanEntry{
+					{
+						Providers: []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}, {ID: "B", Version: "v2"}},
+						Requires:  []buildpack.Require{{Name: "dep1"}},
+					},
+				}}
+				bpA = testmock.NewMockBuildpack(mockCtrl)
+				bpB = testmock.NewMockBuildpack(mockCtrl)
+				bpA.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+				bpB.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).DoAndReturn(
+					func(_ buildpack.Plan, _ buildpack.BuildConfig, _ buildpack.BuildEnv) (buildpack.BuildResult, error) {
+						h.Mkdir(t, filepath.Join(layersDir, "A", "layer"))
+						h.Mkfile(t, "[types]\nlaunch = true", filepath.Join(layersDir, "A", "layer.toml"))
+						return buildpack.BuildResult{
+							BOM:         []buildpack.BOMEntry{{Require: buildpack.Require{Name: "dep1"}}},
+							MetRequires: []string{"dep1"},
+							Processes:   []launch.Process{{Type: "web", Command: "run-a", BuildpackID: "A"}},
+						}, nil
+					})
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, errors.New("some error"))
+
+				if _, err := builder.Build(); err == nil {
+					t.Fatal("Expected error.\n")
+				}
+			})
+
+			it("should skip buildpacks that completed the previous build", func() {
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).DoAndReturn(
+					func(bpPlan buildpack.Plan, _ buildpack.BuildConfig, _ buildpack.BuildEnv) (buildpack.BuildResult, error) {
+						h.AssertEq(t, len(bpPlan.Entries), 0)
+						return buildpack.BuildResult{
+							Processes: []launch.Process{{Type: "worker", Command: "run-b", BuildpackID: "B"}},
+						}, nil
+					})
+				metadata, err := builder.Build()
+				h.AssertNil(t, err)
+				h.AssertEq(t, metadata.BOM, []buildpack.BOMEntry{{Require: buildpack.Require{Name: "dep1"}}})
+				h.AssertEq(t, metadata.Processes, []launch.Process{
+					{Type: "web", Command: "run-a", BuildpackID: "A"},
+					{Type: "worker", Command: "run-b", BuildpackID: "B"},
+				})
+				h.AssertStringContains(t, h.AllLogs(logHandler), "Skipping buildpack A@v1, completed by previous build")
+				h.AssertPathDoesNotExist(t, builder.CheckpointPath)
+			})
+
+			it("should skip buildpacks that completed before a later buildpack changed the app directory", func() {
+				builder.Group.Group = append(builder.Group.Group, buildpack.GroupBuildpack{ID: "C", Version: "v3", API: api.Buildpack.Latest().String()})
+				bpC := testmock.NewMockBuildpack(mockCtrl)
+				bpC.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).DoAndReturn(
+					func(_ buildpack.Plan, _ buildpack.BuildConfig, _ buildpack.BuildEnv) (buildpack.BuildResult, error) {
+						h.Mkfile(t, "generated", filepath.Join(appDir, "generated-file"))
+						return buildpack.BuildResult{}, nil
+					})
+				buildpackStore.EXPECT().Lookup("C", "v3").Return(bpC, nil).Times(2)
+				gomock.InOrder(
+					bpC.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, errors.New("some error")),
+					bpC.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil),
+				)
+				if _, err := builder.Build(); err == nil {
+					t.Fatal("Expected error.\n")
+				}
+
+				logHandler.Entries = nil
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+				h.AssertStringContains(t, h.AllLogs(logHandler), "Skipping buildpack A@v1, completed by previous build")
+				h.AssertStringContains(t, h.AllLogs(logHandler), "Skipping buildpack B@v2, completed by previous build")
+			})
+
+			it("should rebuild buildpacks whose layers changed", func() {
+				h.Mkfile(t, "changed", filepath.Join(layersDir, "A", "layer", "file"))
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+			})
+
+			it("should rebuild every buildpack if the app directory changed", func() {
+				h.Mkfile(t, "changed", filepath.Join(appDir, "some-file"))
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+			})
+
+			it("should rebuild every buildpack if the plan changed", func() {
+				builder.Plan = platform.BuildPlan{Entries: []platform.BuildPlanEntry{
+					{
+						Providers: []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}},
+						Requires:  []buildpack.Require{{Name: "dep2"}},
+					},
+				}}
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+			})
+		})
+
 		when("platform api < 0.4", func() {
 			it.Before(func() {
 				builder.PlatformAPI = api.MustParse("0.3")
@@ -855,3 +1138,17 @@ func testBuilder(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 }
+
+// attributingLogger prefixes the records of the loggers it returns with their attribution.
+type attributingLogger struct {
+	lifecycle.Logger
+	prefix string
+}
+
+func (l *attributingLogger) WithAttribution(key, value string) lifecycle.Logger {
+	return &attributingLogger{Logger: l.Logger, prefix: key + "=" + value + ": "}
+}
+
+func (l *attributingLogger) Info(msg string) {
+	l.Logger.Info(l.prefix + msg)
+}
@@ -1,7 +1,6 @@
 package buildpack
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -9,8 +8,10 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/buildpack/layertypes"
@@ -30,17 +31,23 @@ type BuildConfig struct {
 	AppDir      string
 	PlatformDir string
 	LayersDir   string
+	Timeout     time.Duration
+	Offline     bool // runs /bin/build without network access
+	Strict      bool // fails the build when the buildpack violates the layer contract
+	LogPrefix   LogPrefix
 	Out         io.Writer
 	Err         io.Writer
 	Logger      Logger
 }
 
 type BuildResult struct {
-	BOM         []BOMEntry
-	Labels      []Label
-	MetRequires []string
-	Processes   []launch.Process
-	Slices      []layers.Slice
+	BOM         []BOMEntry       `toml:"bom"`
+	Labels      []Label          `toml:"labels"`
+	MetRequires []string         `toml:"met-requires"`
+	Processes   []launch.Process `toml:"processes"`
+	Slices      []layers.Slice   `toml:"slices"`
+	Unmet       []string         `toml:"unmet"` // names of the requirements the buildpack declared unmet
+	Usage       Usage            `toml:"-"`
 }
 
 func (bom *BOMEntry) ConvertMetadataToVersion() {
@@ -85,8 +92,10 @@ func (b *Descriptor) Build(bpPlan Plan, config BuildConfig, bpEnv BuildEnv) (Bui
 	}
 
 	config.Logger.Debug("Running build command")
-	if err := b.runBuildCmd(bpLayersDir, bpPlanPath, config, bpEnv); err != nil {
-		return BuildResult{}, err
+	usage, err := b.runBuildCmd(bpLayersDir, bpPlanPath, config, bpEnv)
+	if err != nil {
+		// the usage of a failed build is still reported
+		return BuildResult{Usage: usage}, err
 	}
 
 	config.Logger.Debug("Processing layers")
@@ -95,13 +104,44 @@ func (b *Descriptor) Build(bpPlan Plan, config BuildConfig, bpEnv BuildEnv) (Bui
 		return BuildResult{}, err
 	}
 
+	config.Logger.Debug("Validating layers")
+	if err := b.lintLayers(bpLayersDir, pathToLayerMetadataFile, config); err != nil {
+		return BuildResult{}, err
+	}
+
 	config.Logger.Debug("Updating environment")
 	if err := b.setupEnv(pathToLayerMetadataFile, bpEnv); err != nil {
 		return BuildResult{}, err
 	}
 
 	config.Logger.Debug("Reading output files")
-	return b.readOutputFiles(bpLayersDir, bpPlanPath, bpPlan, config.Logger)
+	br, err := b.readOutputFiles(bpLayersDir, bpPlanPath, bpPlan, config.Logger)
+	if err != nil {
+		return BuildResult{}, err
+	}
+	br.Usage = usage
+	return br, nil
+}
+
+// lintLayers warns about each violation of the layer contract, or fails if config.Strict is set.
+func (b *Descriptor) lintLayers(bpLayersDir string, pathToLayerMetadataFile map[string]layertypes.LayerMetadataFile, config BuildConfig) error {
+	problems, err := lintLayers(b.API, bpLayersDir, config.LayersDir, pathToLayerMetadataFile)
+	if err != nil {
+		return errors.Wrap(err, "validating layers")
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	if config.Strict {
+		return NewLifecycleError(
+			errors.Errorf("buildpack %s violated the layer contract:\n  %s", b.Buildpack.ID, strings.Join(problems, "\n  ")),
+			ErrTypeBuildpack,
+		)
+	}
+	for _, problem := range problems {
+		config.Logger.Warnf("Buildpack %s: %s", b.Buildpack.ID, problem)
+	}
+	return nil
 }
 
 func (b *Descriptor) SupportsAssetPackages() bool {
@@ -176,7 +216,7 @@ func WriteTOML(path string, data interface{}) error {
 	return toml.NewEncoder(f).Encode(data)
 }
 
-func (b *Descriptor) runBuildCmd(bpLayersDir, bpPlanPath string, config BuildConfig, bpEnv BuildEnv) error {
+func (b *Descriptor) runBuildCmd(bpLayersDir, bpPlanPath string, config BuildConfig, bpEnv BuildEnv) (Usage, error) {
 	cmd := exec.Command(
 		filepath.Join(b.Dir, "bin", "build"),
 		bpLayersDir,
@@ -184,8 +224,8 @@ func (b *Descriptor) runBuildCmd(bpLayersDir, bpPlanPath string, config BuildCon
 		bpPlanPath,
 	) // #nosec G204
 	cmd.Dir = config.AppDir
-	cmd.Stdout = config.Out
-	cmd.Stderr = config.Err
+	var flushOutput func()
+	cmd.Stdout, cmd.Stderr, flushOutput = prefixOutput(config.LogPrefix, b.Buildpack.ID, config.Out, config.Err)
 
 	var err error
 	if b.Buildpack.ClearEnv {
@@ -193,15 +233,38 @@ func (b *Descriptor) runBuildCmd(bpLayersDir, bpPlanPath string, config BuildCon
 	} else {
 		cmd.Env, err = bpEnv.WithPlatform(config.PlatformDir)
 		if err != nil {
-			return err
+			return Usage{}, err
 		}
 	}
 	cmd.Env = append(cmd.Env, EnvBuildpackDir+"="+b.Dir)
 
-	if err := cmd.Run(); err != nil {
-		return NewLifecycleError(err, ErrTypeBuildpack)
+	timeout, err := effectiveTimeout(config.Timeout, b.Buildpack.BuildTimeout)
+	if err != nil {
+		return Usage{}, err
 	}
-	return nil
+	var status *offlineStatus
+	if config.Offline {
+		if status, err = isolateNetwork(cmd); err != nil {
+			return Usage{}, err
+		}
+	}
+	err = runCmd(cmd, timeout)
+	offlineErr := status.err()
+	flushOutput()
+	usage := processUsage(cmd.ProcessState)
+	if err != nil {
+		if _, ok := err.(*TimeoutError); ok {
+			return usage, NewLifecycleError(err, ErrTypeTimeout)
+		}
+		if offlineErr != nil {
+			return usage, errors.Wrapf(offlineErr, "failed to run buildpack %s in offline mode", b.Buildpack.ID)
+		}
+		if err, ok := err.(*exec.ExitError); ok && config.Offline {
+			return usage, NewLifecycleError(errors.Wrapf(err, "buildpack %s failed (network access was disabled)", b.Buildpack.ID), ErrTypeBuildpack)
+		}
+		return usage, NewLifecycleError(err, ErrTypeBuildpack)
+	}
+	return usage, nil
 }
 
 func (b *Descriptor) setupEnv(pathToLayerMetadataFile map[string]layertypes.LayerMetadataFile, buildEnv BuildEnv) error {
@@ -292,6 +355,9 @@ func (b *Descriptor) readOutputFiles(bpLayersDir, bpPlanPath string, bpPlanIn Pl
 			return BuildResult{}, err
 		}
 		br.MetRequires = names(bpPlanIn.filter(bpBuild.Unmet).Entries)
+		for _, unmet := range bpBuild.Unmet {
+			br.Unmet = append(br.Unmet, unmet.Name)
+		}
 
 		// read launch.toml, return if not exists
 		if _, err := toml.DecodeFile(launchPath, &launchTOML); os.IsNotExist(err) {
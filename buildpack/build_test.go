@@ -7,14 +7,17 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
@@ -27,7 +30,10 @@ import (
 	"github.com/buildpacks/lifecycle/testmock"
 )
 
-var latestBuildpackAPI = api.Buildpack.Latest()
+var (
+	latestBuildpackAPI = api.Buildpack.Latest()
+	ignoreUsage        = cmpopts.IgnoreFields(buildpack.BuildResult{}, "Usage")
+)
 
 func TestBuild(t *testing.T) {
 	spec.Run(t, "Build", testBuild, spec.Report(report.Terminal{}))
@@ -133,6 +139,16 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 				)
 			})
 
+			it("should report the resources used by /bin/build", func() {
+				br, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+				if err != nil {
+					t.Fatalf("Unexpected error:\n%s\n", err)
+				}
+				if runtime.GOOS != "windows" && br.Usage.MaxRSS == 0 {
+					t.Fatalf("Expected max RSS to be reported:\n%+v\n", br.Usage)
+				}
+			})
+
 			it("should provide the platform dir", func() {
 				h.Mkfile(t, "some-data",
 					filepath.Join(platformDir, "env", "SOME_VAR"),
@@ -179,6 +195,34 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 				}
 			})
 
+			when("the log prefix is set", func() {
+				it.Before(func() {
+					h.Mkfile(t, "partial", filepath.Join(appDir, "build-out-A-v1"))
+				})
+
+				it("should prefix each line of stdout and stderr with the buildpack ID", func() {
+					config.LogPrefix = buildpack.LogPrefixBuildpack
+					if _, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv); err != nil {
+						t.Fatalf("Unexpected error:\n%s\n", err)
+					}
+					if s := cmp.Diff(h.CleanEndings(stdout.String()), "[A] build out: A@v1\n[A] partial\n"); s != "" {
+						t.Fatalf("Unexpected stdout:\n%s\n", s)
+					}
+					if s := cmp.Diff(h.CleanEndings(stderr.String()), "[A] build err: A@v1\n"); s != "" {
+						t.Fatalf("Unexpected stderr:\n%s\n", s)
+					}
+				})
+
+				it("should prefix each line with the time elapsed", func() {
+					config.LogPrefix = buildpack.LogPrefixTime
+					if _, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv); err != nil {
+						t.Fatalf("Unexpected error:\n%s\n", err)
+					}
+					h.AssertMatch(t, h.CleanEndings(stdout.String()), `^\[A \+\d+\.\d{3}s\] build out: A@v1\n\[A \+\d+\.\d{3}s\] partial\n$`)
+					h.AssertMatch(t, h.CleanEndings(stderr.String()), `^\[A \+\d+\.\d{3}s\] build err: A@v1\n$`)
+				})
+			})
+
 			when("build result", func() {
 				it("should get bom entries from launch.toml and unmet requires from build.toml", func() {
 					bpPlan := buildpack.Plan{
@@ -256,7 +300,8 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 						MetRequires: []string{"some-deprecated-bp-replace-version-dep", "some-dep", "some-replace-version-dep"},
 						Processes:   []launch.Process{},
 						Slices:      []layers.Slice{},
-					}); s != "" {
+						Unmet:       []string{"some-unmet-dep"},
+					}, ignoreUsage); s != "" {
 						t.Fatalf("Unexpected:\n%s\n", s)
 					}
 				})
@@ -286,7 +331,7 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 						MetRequires: nil,
 						Processes:   []launch.Process{},
 						Slices:      []layers.Slice{},
-					}); s != "" {
+					}, ignoreUsage); s != "" {
 						t.Fatalf("Unexpected:\n%s\n", s)
 					}
 				})
@@ -317,7 +362,7 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 								{Type: "web", Command: "other-cmd", BuildpackID: "A", Default: false},
 							},
 							Slices: []layers.Slice{},
-						}); s != "" {
+						}, ignoreUsage); s != "" {
 							t.Fatalf("Unexpected metadata:\n%s\n", s)
 						}
 					})
@@ -341,7 +386,7 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 						MetRequires: nil,
 						Processes:   []launch.Process{},
 						Slices:      []layers.Slice{{Paths: []string{"some-path", "some-other-path"}}},
-					}); s != "" {
+					}, ignoreUsage); s != "" {
 						t.Fatalf("Unexpected:\n%s\n", s)
 					}
 				})
@@ -382,6 +427,76 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 					})
 				})
 			})
+			when("the layers violate the layer contract", func() {
+				it.Before(func() {
+					h.Mkdir(t,
+						filepath.Join(layersDir, "A", "empty-layer"),
+						filepath.Join(layersDir, "A", "link-layer"),
+					)
+					h.Mkfile(t, "some-key = \"some-value\"\n[types]\n  launch = true", filepath.Join(layersDir, "A", "empty-layer.toml"))
+					h.Mkfile(t, "[types]\n  cache = true", filepath.Join(layersDir, "A", "link-layer.toml"))
+					h.Mkfile(t, "some-data", filepath.Join(layersDir, "A", "some-file"))
+					h.AssertNil(t, os.Symlink("/etc", filepath.Join(layersDir, "A", "link-layer", "some-link")))
+					h.AssertNil(t, os.Symlink("../empty-layer", filepath.Join(layersDir, "A", "link-layer", "inside-link")))
+				})
+
+				it("warns about each violation", func() {
+					_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+					h.AssertNil(t, err)
+
+					assertLogEntry(t, logHandler, "Buildpack A: file 'empty-layer.toml' has unknown keys: some-key")
+					assertLogEntry(t, logHandler, "Buildpack A: symlink 'link-layer/some-link' points outside the layers directory to '/etc'")
+					assertLogEntry(t, logHandler, "Buildpack A: file 'some-file' is not a layer directory or a TOML file")
+					assertLogEntry(t, logHandler, "Buildpack A: launch layer 'empty-layer' is empty")
+					if strings.Contains(h.AllLogs(logHandler), "inside-link") {
+						t.Fatalf("Unexpected warning about a symlink inside the layers directory:\n%s\n", h.AllLogs(logHandler))
+					}
+				})
+
+				when("buildpack api < 0.6", func() {
+					it("warns about the [types] table, which the buildpack API ignores", func() {
+						bpTOML.API = "0.5"
+						h.Mkfile(t, "cache = true\n[types]\n  launch = true", filepath.Join(layersDir, "A", "link-layer.toml"))
+
+						_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+						h.AssertNil(t, err)
+
+						assertLogEntry(t, logHandler, "Buildpack A: file 'link-layer.toml' has unknown keys: types, types.launch")
+					})
+				})
+
+				when("strict", func() {
+					it("fails the build", func() {
+						config.Strict = true
+						_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+						if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeBuildpack {
+							t.Fatalf("Unexpected error:\n%s\n", err)
+						}
+						h.AssertStringContains(t, err.Error(), "buildpack A violated the layer contract")
+						h.AssertStringContains(t, err.Error(), "launch layer 'empty-layer' is empty")
+					})
+				})
+			})
+
+			when("a cache layer was restored", func() {
+				it.Before(func() {
+					h.Mkdir(t, filepath.Join(layersDir, "A", "cache-layer"))
+					h.Mkfile(t, "[types]\n  cache = true", filepath.Join(layersDir, "A", "cache-layer.toml"))
+					h.Mkfile(t, "some-data", filepath.Join(layersDir, "A", "cache-layer", "some-file"))
+					h.Mkfile(t, "sha256:some-sha", filepath.Join(layersDir, "A", "cache-layer.sha"))
+					h.Mkfile(t, "sha256:other-sha", filepath.Join(layersDir, "A", "orphan-layer.sha"))
+				})
+
+				it("allows the layer's sha file", func() {
+					_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+					h.AssertNil(t, err)
+
+					if strings.Contains(h.AllLogs(logHandler), "cache-layer.sha") {
+						t.Fatalf("Unexpected warning about a restored layer:\n%s\n", h.AllLogs(logHandler))
+					}
+					assertLogEntry(t, logHandler, "Buildpack A: file 'orphan-layer.sha' is not a layer directory or a TOML file")
+				})
+			})
 		})
 
 		when("building succeeds with a clear env", func() {
@@ -460,6 +575,33 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 				}
 			})
 
+			when("the command exceeds its timeout", func() {
+				it.Before(func() {
+					mockEnv.EXPECT().WithPlatform(platformDir).Return(append(os.Environ(), "TEST_ENV=Av1"), nil)
+					h.Mkfile(t, "10", filepath.Join(appDir, "build-sleep-A-v1"))
+				})
+
+				it("should kill the buildpack and return a timeout error", func() {
+					config.Timeout = 100 * time.Millisecond
+					start := time.Now()
+					_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+					if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeTimeout {
+						t.Fatalf("Incorrect error: %s\n", err)
+					}
+					if elapsed := time.Since(start); elapsed > 5*time.Second {
+						t.Fatalf("Build was not killed after timeout, took %s", elapsed)
+					}
+				})
+
+				it("should use the timeout from buildpack.toml", func() {
+					bpTOML.Buildpack.BuildTimeout = "100ms"
+					_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+					if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeTimeout {
+						t.Fatalf("Incorrect error: %s\n", err)
+					}
+				})
+			})
+
 			when("modifying the env fails", func() {
 				var appendErr error
 
@@ -791,7 +933,7 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 						},
 						Processes: nil,
 						Slices:    nil,
-					}); s != "" {
+					}, ignoreUsage); s != "" {
 						t.Fatalf("Unexpected:\n%s\n", s)
 					}
 				})
@@ -905,7 +1047,7 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 						{Type: "type-with-default", Command: "other-cmd", BuildpackID: "A", Default: false},
 					},
 					Slices: []layers.Slice{},
-				}); s != "" {
+				}, ignoreUsage); s != "" {
 					t.Fatalf("Unexpected metadata:\n%s\n", s)
 				}
 				expected := "Warning: default processes aren't supported in this buildpack api version. Overriding the default value to false for the following processes: [type-with-default]"
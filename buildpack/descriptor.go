@@ -22,11 +22,14 @@ func (b *Descriptor) String() string {
 }
 
 type Info struct {
-	ClearEnv bool   `toml:"clear-env,omitempty"`
-	Homepage string `toml:"homepage,omitempty"`
-	ID       string `toml:"id"`
-	Name     string `toml:"name"`
-	Version  string `toml:"version"`
+	BuildTimeout  string   `toml:"build-timeout,omitempty"`
+	ClearEnv      bool     `toml:"clear-env,omitempty"`
+	DetectInputs  []string `toml:"detect-inputs,omitempty"` // app dir globs inspected by /bin/detect, used to key cached detect results
+	DetectTimeout string   `toml:"detect-timeout,omitempty"`
+	Homepage      string   `toml:"homepage,omitempty"`
+	ID            string   `toml:"id"`
+	Name          string   `toml:"name"`
+	Version       string   `toml:"version"`
 }
 
 type Order []Group
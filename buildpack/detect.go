@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
@@ -16,6 +17,12 @@ import (
 
 const EnvBuildpackDir = "CNB_BUILDPACK_DIR"
 
+// CodeDetectTimeout is the DetectRun code of a bin/detect that was killed after exceeding its timeout.
+const CodeDetectTimeout = -2
+
+// CodeDetectOfflineFailed is the DetectRun code of a bin/detect that the lifecycle failed to run in offline mode.
+const CodeDetectOfflineFailed = -3
+
 type Logger interface {
 	Debug(msg string)
 	Debugf(fmt string, v ...interface{})
@@ -33,10 +40,20 @@ type Logger interface {
 type DetectConfig struct {
 	AppDir      string
 	PlatformDir string
+	Timeout     time.Duration
+	Offline     bool // runs /bin/detect without network access
+	LogPrefix   LogPrefix
 	Logger      Logger
 }
 
 func (b *Descriptor) Detect(config *DetectConfig, bpEnv BuildEnv) DetectRun {
+	start := time.Now()
+	run := b.detect(config, bpEnv)
+	run.Duration = time.Since(start)
+	return run
+}
+
+func (b *Descriptor) detect(config *DetectConfig, bpEnv BuildEnv) DetectRun {
 	appDir, err := filepath.Abs(config.AppDir)
 	if err != nil {
 		return DetectRun{Code: -1, Err: err}
@@ -45,6 +62,10 @@ func (b *Descriptor) Detect(config *DetectConfig, bpEnv BuildEnv) DetectRun {
 	if err != nil {
 		return DetectRun{Code: -1, Err: err}
 	}
+	timeout, err := effectiveTimeout(config.Timeout, b.Buildpack.DetectTimeout)
+	if err != nil {
+		return DetectRun{Code: -1, Err: err}
+	}
 	planDir, err := ioutil.TempDir("", "plan.")
 	if err != nil {
 		return DetectRun{Code: -1, Err: err}
@@ -63,8 +84,8 @@ func (b *Descriptor) Detect(config *DetectConfig, bpEnv BuildEnv) DetectRun {
 		planPath,
 	) // #nosec G204
 	cmd.Dir = appDir
-	cmd.Stdout = out
-	cmd.Stderr = out
+	var flushOutput func()
+	cmd.Stdout, cmd.Stderr, flushOutput = prefixOutput(config.LogPrefix, b.Buildpack.ID, out, out)
 
 	if b.Buildpack.ClearEnv {
 		cmd.Env = bpEnv.List()
@@ -76,17 +97,38 @@ func (b *Descriptor) Detect(config *DetectConfig, bpEnv BuildEnv) DetectRun {
 	}
 	cmd.Env = append(cmd.Env, EnvBuildpackDir+"="+b.Dir)
 
-	if err := cmd.Run(); err != nil {
+	var status *offlineStatus
+	if config.Offline {
+		if status, err = isolateNetwork(cmd); err != nil {
+			return DetectRun{Code: CodeDetectOfflineFailed, Err: err}
+		}
+	}
+	err = runCmd(cmd, timeout)
+	offlineErr := status.err()
+	flushOutput()
+	usage := processUsage(cmd.ProcessState)
+	if err != nil {
+		if err, ok := err.(*TimeoutError); ok {
+			return DetectRun{Code: CodeDetectTimeout, Err: err, Output: out.Bytes(), Usage: usage}
+		}
+		if offlineErr != nil {
+			err := errors.Wrapf(offlineErr, "failed to run buildpack %s in offline mode", b.Buildpack.ID)
+			return DetectRun{Code: CodeDetectOfflineFailed, Err: err, Output: out.Bytes(), Usage: usage}
+		}
 		if err, ok := err.(*exec.ExitError); ok {
 			if status, ok := err.Sys().(syscall.WaitStatus); ok {
-				return DetectRun{Code: status.ExitStatus(), Output: out.Bytes()}
+				run := DetectRun{Code: status.ExitStatus(), Output: out.Bytes(), Usage: usage}
+				if config.Offline && run.Code != 100 { // any other non-zero code is an error
+					run.Err = errors.Errorf("buildpack %s failed (network access was disabled)", b.Buildpack.ID)
+				}
+				return run
 			}
 		}
-		return DetectRun{Code: -1, Err: err, Output: out.Bytes()}
+		return DetectRun{Code: -1, Err: err, Output: out.Bytes(), Usage: usage}
 	}
 	var t DetectRun
 	if _, err := toml.DecodeFile(planPath, &t); err != nil {
-		return DetectRun{Code: -1, Err: err}
+		return DetectRun{Code: -1, Err: err, Usage: usage}
 	}
 	if api.MustParse(b.API).Equal(api.MustParse("0.2")) {
 		if t.hasInconsistentVersions() || t.Or.hasInconsistentVersions() {
@@ -106,12 +148,15 @@ func (b *Descriptor) Detect(config *DetectConfig, bpEnv BuildEnv) DetectRun {
 		}
 	}
 	t.Output = out.Bytes()
+	t.Usage = usage
 	return t
 }
 
 type DetectRun struct {
 	BuildPlan
-	Output []byte `toml:"-"`
-	Code   int    `toml:"-"`
-	Err    error  `toml:"-"`
+	Output   []byte        `toml:"-"`
+	Code     int           `toml:"-"`
+	Err      error         `toml:"-"`
+	Duration time.Duration `toml:"-"`
+	Usage    Usage         `toml:"-"`
 }
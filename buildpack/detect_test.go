@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
@@ -134,6 +135,37 @@ func testDetect(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("the command exceeds its timeout", func() {
+			it.Before(func() {
+				mockEnv.EXPECT().WithPlatform(platformDir).Return(append(os.Environ(), someEnv), nil)
+				toappfile("10", "detect-sleep-A-v1")
+			})
+
+			it("should kill the buildpack and return a timeout code", func() {
+				detectConfig.Timeout = 100 * time.Millisecond
+				start := time.Now()
+
+				detectRun := bpTOML.Detect(&detectConfig, mockEnv)
+
+				h.AssertEq(t, detectRun.Code, buildpack.CodeDetectTimeout)
+				if _, ok := detectRun.Err.(*buildpack.TimeoutError); !ok {
+					t.Fatalf("Unexpected error:\n%s\n", detectRun.Err)
+				}
+				if elapsed := time.Since(start); elapsed > 5*time.Second {
+					t.Fatalf("Detect was not killed after timeout, took %s", elapsed)
+				}
+			})
+
+			it("should prefer the shorter of the platform and buildpack.toml timeouts", func() {
+				detectConfig.Timeout = time.Hour
+				bpTOML.Buildpack.DetectTimeout = "100ms"
+
+				detectRun := bpTOML.Detect(&detectConfig, mockEnv)
+
+				h.AssertEq(t, detectRun.Code, buildpack.CodeDetectTimeout)
+			})
+		})
+
 		it("should set CNB_BUILDPACK_DIR in the environment", func() {
 			mockEnv.EXPECT().WithPlatform(platformDir).Return(append(os.Environ(), someEnv), nil)
 
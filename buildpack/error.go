@@ -4,6 +4,8 @@ type ErrorType string
 
 const ErrTypeBuildpack ErrorType = "ERR_BUILDPACK"
 const ErrTypeFailedDetection ErrorType = "ERR_FAILED_DETECTION"
+const ErrTypeCyclicOrder ErrorType = "ERR_CYCLIC_ORDER"
+const ErrTypeTimeout ErrorType = "ERR_TIMEOUT"
 
 type Error struct {
 	RootError error
@@ -0,0 +1,74 @@
+package buildpack
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// A TimeoutError is returned when a buildpack executable is killed after exceeding its timeout.
+type TimeoutError struct {
+	Executable string
+	Timeout    time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", e.Executable, e.Timeout)
+}
+
+// Usage records the resources used by a buildpack executable.
+type Usage struct {
+	UserTime time.Duration
+	SysTime  time.Duration
+	MaxRSS   int64 // peak resident set size in kilobytes, where reported by the OS
+}
+
+func processUsage(state *os.ProcessState) Usage {
+	if state == nil {
+		return Usage{}
+	}
+	return Usage{
+		UserTime: state.UserTime(),
+		SysTime:  state.SystemTime(),
+		MaxRSS:   maxRSS(state),
+	}
+}
+
+// runCmd runs cmd to completion. If timeout is non-zero and cmd has not exited once it elapses,
+// the process group of cmd is killed and a *TimeoutError is returned.
+func runCmd(cmd *exec.Cmd, timeout time.Duration) error {
+	if timeout <= 0 {
+		return cmd.Run()
+	}
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	timer := time.AfterFunc(timeout, func() {
+		_ = killProcessGroup(cmd)
+	})
+	err := cmd.Wait()
+	if !timer.Stop() {
+		return &TimeoutError{Executable: cmd.Path, Timeout: timeout}
+	}
+	return err
+}
+
+// effectiveTimeout returns the smaller of the platform timeout and the timeout declared in buildpack.toml,
+// ignoring either when it is unset.
+func effectiveTimeout(platformTimeout time.Duration, bpTimeout string) (time.Duration, error) {
+	if bpTimeout == "" {
+		return platformTimeout, nil
+	}
+	timeout, err := time.ParseDuration(bpTimeout)
+	if err != nil {
+		return 0, errors.Wrapf(err, "parse buildpack timeout '%s'", bpTimeout)
+	}
+	if platformTimeout > 0 && platformTimeout < timeout {
+		return platformTimeout, nil
+	}
+	return timeout, nil
+}
@@ -0,0 +1,24 @@
+package buildpack
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= syscall.CREATE_NEW_PROCESS_GROUP
+}
+
+// killProcessGroup only kills the buildpack process, as Windows does not support signalling a process group.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
+
+// maxRSS is not reported for Windows processes.
+func maxRSS(_ *os.ProcessState) int64 {
+	return 0
+}
@@ -94,7 +94,7 @@ type StoreTOML struct {
 
 type BuildPlan struct {
 	PlanSections
-	Or planSectionsList `toml:"or"`
+	Or planSectionsList `toml:"or" json:"or,omitempty"`
 }
 
 func (p *PlanSections) hasInconsistentVersions() bool {
@@ -154,12 +154,13 @@ func (p *planSectionsList) hasTopLevelVersions() bool {
 }
 
 type PlanSections struct {
-	Requires []Require `toml:"requires"`
-	Provides []Provide `toml:"provides"`
+	Requires []Require `toml:"requires" json:"requires,omitempty"`
+	Provides []Provide `toml:"provides" json:"provides,omitempty"`
 }
 
 type Provide struct {
-	Name string `toml:"name"`
+	Name     string   `toml:"name" json:"name"`
+	Versions []string `toml:"versions,omitempty" json:"versions,omitempty"` // only used when the platform enables plan version constraints
 }
 
 // buildpack plan
@@ -0,0 +1,164 @@
+package buildpack
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/buildpack/layertypes"
+)
+
+// lintLayers validates the contents of bpLayersDir against the layer contract of bpAPI after /bin/build has run,
+// and returns a description of each violation.
+func lintLayers(bpAPI, bpLayersDir, layersDir string, pathToLayerMetadataFile map[string]layertypes.LayerMetadataFile) ([]string, error) {
+	var problems []string
+
+	fis, err := ioutil.ReadDir(bpLayersDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, fi := range fis {
+		path := filepath.Join(bpLayersDir, fi.Name())
+		switch {
+		case fi.IsDir():
+			layerProblems, err := lintLayerDir(path, layersDir)
+			if err != nil {
+				return nil, err
+			}
+			problems = append(problems, layerProblems...)
+		case isLayerSHAFile(bpLayersDir, fi.Name()):
+		case filepath.Ext(fi.Name()) != ".toml":
+			problems = append(problems, fmt.Sprintf("file '%s' is not a layer directory or a TOML file", fi.Name()))
+		case isLayerMetadataFile(fi.Name()):
+			keys, err := unknownLayerMetadataKeys(bpAPI, path)
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) > 0 {
+				problems = append(problems, fmt.Sprintf("file '%s' has unknown keys: %s", fi.Name(), strings.Join(keys, ", ")))
+			}
+		}
+	}
+
+	var paths []string
+	for path, layerMetadataFile := range pathToLayerMetadataFile {
+		if layerMetadataFile.Launch {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		empty, err := isEmptyDir(path)
+		if err != nil {
+			return nil, err
+		}
+		if empty {
+			problems = append(problems, fmt.Sprintf("launch layer '%s' is empty", filepath.Base(path)))
+		}
+	}
+	return problems, nil
+}
+
+// lintLayerDir reports symlinks in layerDir that resolve to a path outside layersDir.
+func lintLayerDir(layerDir, layersDir string) ([]string, error) {
+	var problems []string
+	err := filepath.Walk(layerDir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		if !isWithin(filepath.Clean(target), layersDir) {
+			rel, err := filepath.Rel(filepath.Dir(layerDir), path)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, fmt.Sprintf("symlink '%s' points outside the layers directory to '%s'", rel, target))
+		}
+		return nil
+	})
+	return problems, err
+}
+
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(filepath.Clean(dir), path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// isLayerSHAFile returns true for a <layer>.sha file, which the restorer writes next to a layer restored from the cache.
+func isLayerSHAFile(bpLayersDir, name string) bool {
+	if filepath.Ext(name) != ".sha" {
+		return false
+	}
+	layer := strings.TrimSuffix(name, ".sha")
+	for _, path := range []string{layer, layer + ".toml"} {
+		if _, err := os.Stat(filepath.Join(bpLayersDir, path)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func isLayerMetadataFile(name string) bool {
+	switch name {
+	case "launch.toml", "build.toml", "store.toml":
+		return false
+	}
+	return true
+}
+
+// unknownLayerMetadataKeys returns the keys of a <layer>.toml file that are not part of bpAPI,
+// which are ignored when the layer is processed.
+// Buildpack API < 0.6 declares the layer types as top-level booleans, later APIs in the [types] table.
+func unknownLayerMetadataKeys(bpAPI, path string) ([]string, error) {
+	type typesTable struct {
+		Build  bool `toml:"build"`
+		Launch bool `toml:"launch"`
+		Cache  bool `toml:"cache"`
+	}
+	var lmf interface{}
+	if api.MustParse(bpAPI).Compare(api.MustParse("0.6")) < 0 {
+		lmf = &struct {
+			Data   interface{} `toml:"metadata"`
+			Build  bool        `toml:"build"`
+			Launch bool        `toml:"launch"`
+			Cache  bool        `toml:"cache"`
+		}{}
+	} else {
+		lmf = &struct {
+			Data  interface{} `toml:"metadata"`
+			Types typesTable  `toml:"types"`
+		}{}
+	}
+	md, err := toml.DecodeFile(path, lmf)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, key := range md.Undecoded() {
+		keys = append(keys, key.String())
+	}
+	return keys, nil
+}
+
+func isEmptyDir(path string) (bool, error) {
+	fis, err := ioutil.ReadDir(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return len(fis) == 0, err
+}
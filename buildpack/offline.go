@@ -0,0 +1,44 @@
+package buildpack
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// offlineStatusFd is the file descriptor of the status pipe in a lifecycle started as OfflineArg0.
+// The pipe is closed on exec, so the lifecycle that started it reads nothing unless the buildpack executable
+// could not be executed, in which case it reads the error.
+const offlineStatusFd = 3
+
+// offlineStatus is the read end of the status pipe of a lifecycle started as OfflineArg0.
+type offlineStatus struct {
+	r, w *os.File
+}
+
+func newOfflineStatus() (*offlineStatus, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "create status pipe for offline mode")
+	}
+	return &offlineStatus{r: r, w: w}, nil
+}
+
+// err returns the error reported by the lifecycle, once it has exited, or nil if it executed the buildpack executable.
+func (s *offlineStatus) err() error {
+	if s == nil {
+		return nil
+	}
+	s.w.Close()
+	defer s.r.Close()
+	msg, err := ioutil.ReadAll(s.r)
+	if err != nil {
+		return errors.Wrap(err, "read status of offline mode")
+	}
+	if len(msg) == 0 {
+		return nil
+	}
+	return errors.New(strings.TrimSpace(string(msg)))
+}
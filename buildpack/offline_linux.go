@@ -0,0 +1,100 @@
+package buildpack
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// OfflineArg0 is the name the lifecycle re-executes itself as to run a buildpack executable in offline mode.
+// A lifecycle started with this name must call ExecOffline with its remaining arguments.
+const OfflineArg0 = "cnb-offline-exec"
+
+// isolateNetwork arranges for cmd to run in new user and network namespaces,
+// where the only network interface is loopback.
+// The lifecycle is started in the namespaces first, as bringing up loopback requires CAP_NET_ADMIN,
+// which it drops before executing the buildpack executable.
+// The returned status reports whether it failed to do so, once cmd has exited.
+func isolateNetwork(cmd *exec.Cmd) (*offlineStatus, error) {
+	if len(cmd.ExtraFiles) != 0 { // the status pipe must be offlineStatusFd
+		return nil, errors.New("offline mode does not support extra files")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "locate lifecycle executable for offline mode")
+	}
+	status, err := newOfflineStatus()
+	if err != nil {
+		return nil, err
+	}
+	cmd.ExtraFiles = append(cmd.ExtraFiles, status.w)
+	cmd.Args = append([]string{OfflineArg0, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = self
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+	cmd.SysProcAttr.Cloneflags |= syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET
+	cmd.SysProcAttr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+	cmd.SysProcAttr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+	cmd.SysProcAttr.GidMappingsEnableSetgroups = false
+	cmd.SysProcAttr.AmbientCaps = []uintptr{unix.CAP_NET_ADMIN}
+	return status, nil
+}
+
+// ExecOffline brings up the loopback interface of the current network namespace,
+// drops the capabilities granted by isolateNetwork and replaces the current process with args.
+// It only returns if it fails, after reporting the error on the status pipe.
+func ExecOffline(args []string) error {
+	syscall.CloseOnExec(offlineStatusFd)
+	err := execOffline(args)
+	status := os.NewFile(offlineStatusFd, "status")
+	_, _ = status.WriteString(err.Error())
+	status.Close()
+	return err
+}
+
+func execOffline(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing executable")
+	}
+	if err := loopbackUp(); err != nil {
+		return errors.Wrap(err, "bring up loopback interface")
+	}
+	if err := unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0); err != nil {
+		return errors.Wrap(err, "drop capabilities")
+	}
+	return errors.Wrapf(syscall.Exec(args[0], args, os.Environ()), "execute '%s'", args[0]) // #nosec G204
+}
+
+func loopbackUp() error {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer syscall.Close(fd)
+
+	var ifr struct {
+		name  [syscall.IFNAMSIZ]byte
+		flags uint16
+		_     [22]byte
+	}
+	copy(ifr.name[:], "lo")
+	if err := ioctl(fd, syscall.SIOCGIFFLAGS, unsafe.Pointer(&ifr)); err != nil {
+		return err
+	}
+	ifr.flags |= syscall.IFF_UP
+	return ioctl(fd, syscall.SIOCSIFFLAGS, unsafe.Pointer(&ifr))
+}
+
+func ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package buildpack_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/golang/mock/gomock"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+	"github.com/buildpacks/lifecycle/testmock"
+)
+
+func TestMain(m *testing.M) {
+	// the test binary stands in for the lifecycle when buildpacks are run in offline mode
+	if filepath.Base(os.Args[0]) == buildpack.OfflineArg0 {
+		if err := buildpack.ExecOffline(os.Args[1:]); err != nil {
+			os.Exit(1)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func TestOffline(t *testing.T) {
+	spec.Run(t, "Offline", testOffline, spec.Report(report.Terminal{}))
+}
+
+func testOffline(t *testing.T, when spec.G, it spec.S) {
+	var (
+		bpTOML   buildpack.Descriptor
+		mockCtrl *gomock.Controller
+		mockEnv  *testmock.MockBuildEnv
+		tmpDir   string
+		config   buildpack.BuildConfig
+	)
+
+	it.Before(func() {
+		if err := unshareNetwork(); err != nil {
+			t.Skipf("Network namespaces are not available: %s", err)
+		}
+		mockCtrl = gomock.NewController(t)
+		mockEnv = testmock.NewMockBuildEnv(mockCtrl)
+		mockEnv.EXPECT().WithPlatform(gomock.Any()).Return(os.Environ(), nil)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.offline")
+		h.AssertNil(t, err)
+		appDir := filepath.Join(tmpDir, "app")
+		bpDir := filepath.Join(tmpDir, "buildpack")
+		h.Mkdir(t, appDir, filepath.Join(tmpDir, "platform"), filepath.Join(bpDir, "bin"))
+
+		config = buildpack.BuildConfig{
+			AppDir:      appDir,
+			PlatformDir: filepath.Join(tmpDir, "platform"),
+			LayersDir:   filepath.Join(tmpDir, "layers"),
+			Offline:     true,
+			Out:         &bytes.Buffer{},
+			Err:         &bytes.Buffer{},
+			Logger:      &log.Logger{Handler: memory.New()},
+		}
+		bpTOML = buildpack.Descriptor{
+			API:       latestBuildpackAPI.String(),
+			Buildpack: buildpack.Info{ID: "A", Version: "v1"},
+			Dir:       bpDir,
+		}
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+		if mockCtrl != nil {
+			mockCtrl.Finish()
+		}
+	})
+
+	writeBuild := func(script string) {
+		h.AssertNil(t, ioutil.WriteFile(filepath.Join(bpTOML.Dir, "bin", "build"), []byte("#!/bin/sh\n"+script), 0755))
+	}
+
+	it("runs /bin/build in a new network namespace", func() {
+		writeBuild(`readlink /proc/self/ns/net > "$1/netns"` + "\n")
+
+		_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+		h.AssertNil(t, err)
+
+		netns, err := ioutil.ReadFile(filepath.Join(config.LayersDir, "A", "netns"))
+		h.AssertNil(t, err)
+		hostNetns, err := os.Readlink("/proc/self/ns/net")
+		h.AssertNil(t, err)
+		if strings.TrimSpace(string(netns)) == hostNetns {
+			t.Fatalf("Expected /bin/build to run in a new network namespace, got host namespace '%s'", hostNetns)
+		}
+	})
+
+	it("attributes failures to the buildpack", func() {
+		writeBuild("exit 1\n")
+
+		_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+		if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeBuildpack {
+			t.Fatalf("Unexpected error:\n%s\n", err)
+		}
+		h.AssertStringContains(t, err.Error(), "buildpack A failed (network access was disabled)")
+	})
+
+	it("does not attribute failures to run the buildpack in offline mode to the buildpack", func() {
+		writeBuild("exit 0\n")
+		h.AssertNil(t, os.Chmod(filepath.Join(bpTOML.Dir, "bin", "build"), 0644))
+
+		_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+		if _, ok := err.(*buildpack.Error); ok || err == nil {
+			t.Fatalf("Unexpected error:\n%s\n", err)
+		}
+		h.AssertStringContains(t, err.Error(), "failed to run buildpack A in offline mode")
+		h.AssertStringContains(t, err.Error(), "permission denied")
+	})
+
+	it("attributes any exit code to the buildpack", func() {
+		writeBuild("exit 125\n")
+
+		_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+		if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeBuildpack {
+			t.Fatalf("Unexpected error:\n%s\n", err)
+		}
+		h.AssertStringContains(t, err.Error(), "buildpack A failed (network access was disabled)")
+	})
+}
+
+// unshareNetwork checks whether unprivileged user and network namespaces can be created.
+func unshareNetwork() error {
+	cmd := exec.Command("true")
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+	}
+	return cmd.Run()
+}
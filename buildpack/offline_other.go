@@ -0,0 +1,22 @@
+// +build !linux
+
+package buildpack
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// OfflineArg0 is the name the lifecycle re-executes itself as to run a buildpack executable in offline mode.
+// A lifecycle started with this name must call ExecOffline with its remaining arguments.
+const OfflineArg0 = "cnb-offline-exec"
+
+var errOfflineUnsupported = errors.New("offline mode requires network namespaces, which are only supported on Linux")
+
+func isolateNetwork(_ *exec.Cmd) (*offlineStatus, error) {
+	return nil, errOfflineUnsupported
+}
+
+func ExecOffline(_ []string) error {
+	return errOfflineUnsupported
+}
@@ -0,0 +1,104 @@
+package buildpack
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogPrefix configures the prefix of each line written by /bin/detect and /bin/build.
+type LogPrefix string
+
+const (
+	LogPrefixNone      LogPrefix = "none"
+	LogPrefixBuildpack LogPrefix = "buildpack" // [<buildpack ID>]
+	LogPrefixTime      LogPrefix = "time"      // [<buildpack ID> +<time elapsed since the executable started>]
+)
+
+// ParseLogPrefix parses the value of CNB_LOG_PREFIX, where an empty value is the same as none.
+func ParseLogPrefix(s string) (LogPrefix, error) {
+	switch prefix := LogPrefix(s); prefix {
+	case "":
+		return LogPrefixNone, nil
+	case LogPrefixNone, LogPrefixBuildpack, LogPrefixTime:
+		return prefix, nil
+	default:
+		return "", fmt.Errorf("unknown log prefix '%s', must be one of '%s', '%s' or '%s'", s, LogPrefixNone, LogPrefixBuildpack, LogPrefixTime)
+	}
+}
+
+// prefixOutput returns writers for the stdout and stderr of the buildpack executable with the given ID,
+// which prefix each line written to them before writing it to out and errOut.
+// The returned function writes any remaining partial lines, and must be called after the executable exits.
+func prefixOutput(prefix LogPrefix, bpID string, out, errOut io.Writer) (stdout, stderr io.Writer, flush func()) {
+	if prefix == "" || prefix == LogPrefixNone {
+		return out, errOut, func() {}
+	}
+	start := time.Now()
+	mu := &sync.Mutex{} // the writers may share an underlying writer, and are written to concurrently
+	prefixFn := func() string {
+		if prefix == LogPrefixTime {
+			return fmt.Sprintf("[%s +%.3fs] ", bpID, time.Since(start).Seconds())
+		}
+		return fmt.Sprintf("[%s] ", bpID)
+	}
+	var writers []*prefixWriter
+	wrap := func(w io.Writer) io.Writer {
+		if w == nil {
+			return nil
+		}
+		pw := &prefixWriter{mu: mu, w: w, prefix: prefixFn}
+		writers = append(writers, pw)
+		return pw
+	}
+	stdout, stderr = wrap(out), wrap(errOut)
+	return stdout, stderr, func() {
+		for _, pw := range writers {
+			pw.flush()
+		}
+	}
+}
+
+// prefixWriter prefixes each line written to it.
+// Partial lines are buffered until they are completed, so that lines from different streams are not interleaved.
+type prefixWriter struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix func() string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+func (p *prefixWriter) flush() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.buf) > 0 {
+		_ = p.writeLine(append(p.buf, '\n'))
+		p.buf = nil
+	}
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	_, err := p.w.Write(append([]byte(p.prefix()), line...))
+	return err
+}
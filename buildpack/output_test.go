@@ -0,0 +1,36 @@
+package buildpack_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestOutput(t *testing.T) {
+	spec.Run(t, "Output", testOutput, spec.Report(report.Terminal{}))
+}
+
+func testOutput(t *testing.T, when spec.G, it spec.S) {
+	when("#ParseLogPrefix", func() {
+		it("defaults to no prefix", func() {
+			prefix, err := buildpack.ParseLogPrefix("")
+			h.AssertNil(t, err)
+			h.AssertEq(t, prefix, buildpack.LogPrefixNone)
+		})
+
+		it("parses known prefixes", func() {
+			prefix, err := buildpack.ParseLogPrefix("time")
+			h.AssertNil(t, err)
+			h.AssertEq(t, prefix, buildpack.LogPrefixTime)
+		})
+
+		it("fails for unknown prefixes", func() {
+			_, err := buildpack.ParseLogPrefix("some-prefix")
+			h.AssertError(t, err, "unknown log prefix 'some-prefix'")
+		})
+	})
+}
@@ -0,0 +1,404 @@
+package buildpack
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/archive"
+	"github.com/buildpacks/lifecycle/buildpack/semver"
+)
+
+const (
+	BuildpackageMetadataLabel = "io.buildpacks.buildpackage.metadata"
+	BuildpackLayersLabel      = "io.buildpacks.buildpack.layers"
+)
+
+// BuildpackageMetadata is the content of the io.buildpacks.buildpackage.metadata label.
+type BuildpackageMetadata struct {
+	ID      string `json:"id"`
+	Version string `json:"version"`
+}
+
+// BuildpackLayerInfo describes a single entry of the io.buildpacks.buildpack.layers label.
+type BuildpackLayerInfo struct {
+	API         string `json:"api"`
+	LayerDiffID string `json:"layerDiffID"`
+	Homepage    string `json:"homepage,omitempty"`
+}
+
+// PackageBuildpackStore looks up buildpacks contained in buildpackages, given either as .cnb archives
+// or as OCI image layout directories.
+// Each buildpack layer is extracted to Dir the first time a buildpack it contains is looked up.
+type PackageBuildpackStore struct {
+	Dir string
+
+	buildpacks map[string]map[string]packagedLayer // by ID and version
+	extracted  map[string]bool                     // by layer diffID
+	mu         sync.Mutex
+}
+
+type packagedLayer struct {
+	source blobSource
+	digest v1.Hash
+}
+
+// NewPackageBuildpackStore reads the metadata of each buildpackage in paths.
+// No buildpack layers are extracted until they are needed by Lookup.
+func NewPackageBuildpackStore(paths []string, dir string) (*PackageBuildpackStore, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	store := &PackageBuildpackStore{
+		Dir:        dir,
+		buildpacks: map[string]map[string]packagedLayer{},
+		extracted:  map[string]bool{},
+	}
+	for _, p := range paths {
+		if err := store.add(p); err != nil {
+			return nil, errors.Wrapf(err, "reading buildpackage '%s'", p)
+		}
+	}
+	return store, nil
+}
+
+func (s *PackageBuildpackStore) add(pkgPath string) error {
+	fi, err := os.Stat(pkgPath)
+	if err != nil {
+		return err
+	}
+	var source blobSource
+	if fi.IsDir() {
+		source = dirBlobSource(pkgPath)
+	} else {
+		source = tarBlobSource(pkgPath)
+	}
+
+	manifest, config, err := readImage(source)
+	if err != nil {
+		return err
+	}
+
+	labels := config.Config.Labels
+	if _, ok := labels[BuildpackageMetadataLabel]; !ok {
+		return errors.Errorf("missing label '%s'", BuildpackageMetadataLabel)
+	}
+	var pkgMD BuildpackageMetadata
+	if err := json.Unmarshal([]byte(labels[BuildpackageMetadataLabel]), &pkgMD); err != nil {
+		return errors.Wrapf(err, "parsing label '%s'", BuildpackageMetadataLabel)
+	}
+	var layersMD map[string]map[string]BuildpackLayerInfo
+	if err := json.Unmarshal([]byte(labels[BuildpackLayersLabel]), &layersMD); err != nil {
+		return errors.Wrapf(err, "parsing label '%s'", BuildpackLayersLabel)
+	}
+	if len(config.RootFS.DiffIDs) != len(manifest.Layers) {
+		return errors.New("image config and manifest have a different number of layers")
+	}
+
+	digests := map[string]v1.Hash{}
+	for i, diffID := range config.RootFS.DiffIDs {
+		digests[diffID.String()] = manifest.Layers[i].Digest
+	}
+	for id, versions := range layersMD {
+		for version, info := range versions {
+			digest, ok := digests[info.LayerDiffID]
+			if !ok {
+				return errors.Errorf("layer '%s' for buildpack '%s@%s' not found", info.LayerDiffID, id, version)
+			}
+			if s.buildpacks[id] == nil {
+				s.buildpacks[id] = map[string]packagedLayer{}
+			}
+			s.buildpacks[id][version] = packagedLayer{source: source, digest: digest}
+		}
+	}
+	if _, ok := layersMD[pkgMD.ID][pkgMD.Version]; !ok {
+		return errors.Errorf("buildpackage buildpack '%s@%s' not found in label '%s'", pkgMD.ID, pkgMD.Version, BuildpackLayersLabel)
+	}
+	return nil
+}
+
+// Lookup returns the buildpack with the given ID and version, extracting its layer if necessary.
+// Version ranges are resolved in the same way as by DirBuildpackStore.
+func (s *PackageBuildpackStore) Lookup(bpID, bpVersion string) (Buildpack, error) {
+	versions, ok := s.buildpacks[bpID]
+	if !ok {
+		return nil, errors.Errorf("buildpack '%s' not found in buildpackages", bpID)
+	}
+	if semver.IsConstraint(bpVersion) {
+		version, err := highestVersion(bpID, bpVersion, versions)
+		if err != nil {
+			return nil, err
+		}
+		bpVersion = version
+	}
+	layer, ok := versions[bpVersion]
+	if !ok {
+		return nil, errors.Errorf("buildpack '%s@%s' not found in buildpackages", bpID, bpVersion)
+	}
+	if err := s.extract(layer); err != nil {
+		return nil, errors.Wrapf(err, "extracting buildpack '%s@%s'", bpID, bpVersion)
+	}
+	return (&DirBuildpackStore{Dir: s.Dir}).Lookup(bpID, bpVersion)
+}
+
+func (s *PackageBuildpackStore) extract(layer packagedLayer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.extracted[layer.digest.String()] {
+		return nil
+	}
+	rc, err := layer.source.open(layer.digest)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	r, err := decompress(rc)
+	if err != nil {
+		return err
+	}
+	lr := &buildpackLayerReader{Reader: tar.NewReader(r), dir: s.Dir}
+	if err := archive.Extract(lr); err != nil {
+		return err
+	}
+	if err := lr.createLinks(); err != nil {
+		return err
+	}
+	s.extracted[layer.digest.String()] = true
+	return nil
+}
+
+func highestVersion(bpID, bpVersion string, versions map[string]packagedLayer) (string, error) {
+	constraint, err := semver.NewConstraint(bpVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "buildpack '%s'", bpID)
+	}
+	var (
+		best     *semver.Version
+		bestName string
+	)
+	for name := range versions {
+		v, err := semver.NewVersion(name)
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+		if best == nil || v.Compare(best) > 0 {
+			best, bestName = v, name
+		}
+	}
+	if best == nil {
+		return "", errors.Errorf("no version of buildpack '%s' satisfies '%s'", bpID, bpVersion)
+	}
+	return bestName, nil
+}
+
+// buildpackLayerReader passes through the entries of a buildpack layer that are below /cnb/buildpacks,
+// relocating them to dir.
+// The targets of symlinks to absolute paths below /cnb/buildpacks are relocated as well.
+// Hard links are not passed through, they are created by createLinks once the layer is extracted.
+type buildpackLayerReader struct {
+	*tar.Reader
+	dir   string
+	files map[string]bool // relocated paths of the regular files passed through
+	links []hardLink
+}
+
+type hardLink struct {
+	name, target string
+}
+
+func (r *buildpackLayerReader) Next() (*tar.Header, error) {
+	for {
+		hdr, err := r.Reader.Next()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := r.relocate(hdr.Name)
+		if !ok {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeReg, tar.TypeRegA:
+			if r.files == nil {
+				r.files = map[string]bool{}
+			}
+			r.files[name] = true
+		case tar.TypeLink:
+			// the target must be a file written by an earlier entry, so that it is below dir
+			target, ok := r.relocate(hdr.Linkname)
+			if !ok || !r.files[target] {
+				return nil, errors.Errorf("hard link '%s' has target '%s' that is not an earlier file below /cnb/buildpacks", hdr.Name, hdr.Linkname)
+			}
+			r.links = append(r.links, hardLink{name: name, target: target})
+			continue
+		case tar.TypeSymlink:
+			if linkname, ok := r.relocate(hdr.Linkname); ok && path.IsAbs(hdr.Linkname) {
+				hdr.Linkname = linkname
+			}
+		}
+		hdr.Name = name
+		return hdr, nil
+	}
+}
+
+// createLinks creates the hard links of the layer, once the files they link to are extracted.
+func (r *buildpackLayerReader) createLinks() error {
+	for _, link := range r.links {
+		if err := os.MkdirAll(filepath.Dir(link.name), 0755); err != nil {
+			return errors.Wrapf(err, "failed to create parent dir for hard link %q", link.name)
+		}
+		if err := os.Link(link.target, link.name); err != nil {
+			return errors.Wrapf(err, "failed to create hard link %q with target %q", link.name, link.target)
+		}
+	}
+	return nil
+}
+
+// relocate returns the path in dir of the layer path p, which is false if p is not below /cnb/buildpacks.
+func (r *buildpackLayerReader) relocate(p string) (string, bool) {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	p = strings.TrimPrefix(p, "Files/") // windows layers
+	if !strings.HasPrefix(p, "cnb/buildpacks/") {
+		return "", false
+	}
+	return filepath.Join(r.dir, filepath.FromSlash(strings.TrimPrefix(p, "cnb/buildpacks/"))), true
+}
+
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil
+}
+
+func readImage(source blobSource) (*v1.Manifest, *v1.ConfigFile, error) {
+	rc, err := source.openFile("index.json")
+	if err != nil {
+		return nil, nil, err
+	}
+	index, err := v1.ParseIndexManifest(rc)
+	rc.Close()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing index.json")
+	}
+	desc, err := selectManifest(source, index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rc, err = source.open(desc.Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	manifest, err := v1.ParseManifest(rc)
+	rc.Close()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing manifest")
+	}
+
+	rc, err = source.open(manifest.Config.Digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, err := v1.ParseConfigFile(rc)
+	rc.Close()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "parsing image config")
+	}
+	return manifest, config, nil
+}
+
+// selectManifest returns the image manifest in index matching the current platform,
+// descending into nested indexes, or the first image manifest if none specify a platform.
+func selectManifest(source blobSource, index *v1.IndexManifest) (v1.Descriptor, error) {
+	for _, desc := range index.Manifests {
+		if desc.Platform != nil && (desc.Platform.OS != runtime.GOOS || desc.Platform.Architecture != runtime.GOARCH) {
+			continue
+		}
+		if !desc.MediaType.IsIndex() {
+			return desc, nil
+		}
+		rc, err := source.open(desc.Digest)
+		if err != nil {
+			return v1.Descriptor{}, err
+		}
+		nested, err := v1.ParseIndexManifest(rc)
+		rc.Close()
+		if err != nil {
+			return v1.Descriptor{}, errors.Wrap(err, "parsing nested index")
+		}
+		return selectManifest(source, nested)
+	}
+	return v1.Descriptor{}, errors.New("no image manifest found")
+}
+
+// A blobSource reads the files of an OCI image layout.
+type blobSource interface {
+	openFile(name string) (io.ReadCloser, error)
+	open(digest v1.Hash) (io.ReadCloser, error)
+}
+
+type dirBlobSource string
+
+func (d dirBlobSource) openFile(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+func (d dirBlobSource) open(digest v1.Hash) (io.ReadCloser, error) {
+	return d.openFile(path.Join("blobs", digest.Algorithm, digest.Hex))
+}
+
+// tarBlobSource reads an OCI image layout archived in a tar file, such as a .cnb buildpackage.
+// The archive is scanned each time a file is opened, as tar files do not have an index.
+type tarBlobSource string
+
+func (t tarBlobSource) openFile(name string) (io.ReadCloser, error) {
+	f, err := os.Open(string(t))
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			f.Close()
+			return nil, errors.Errorf("'%s' not found in archive", name)
+		}
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if strings.TrimPrefix(path.Clean("/"+hdr.Name), "/") == name {
+			return &tarEntry{Reader: tr, f: f}, nil
+		}
+	}
+}
+
+func (t tarBlobSource) open(digest v1.Hash) (io.ReadCloser, error) {
+	return t.openFile(path.Join("blobs", digest.Algorithm, digest.Hex))
+}
+
+type tarEntry struct {
+	*tar.Reader
+	f *os.File
+}
+
+func (e *tarEntry) Close() error {
+	return e.f.Close()
+}
@@ -0,0 +1,258 @@
+package buildpack_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestPackageStore(t *testing.T) {
+	spec.Run(t, "PackageStore", testPackageStore, spec.Report(report.Terminal{}))
+}
+
+func testPackageStore(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir     string
+		extractDir string
+		layoutDir  string
+	)
+
+	buildpackLayer := func(id, version, descriptor string, extra ...*tar.Header) v1.Layer {
+		buf := &bytes.Buffer{}
+		tw := tar.NewWriter(buf)
+		dir := "/cnb/buildpacks/" + id + "/" + version
+		for _, d := range []string{"/cnb", "/cnb/buildpacks", "/cnb/buildpacks/" + id, dir} {
+			h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: d, Typeflag: tar.TypeDir, Mode: 0755}))
+		}
+		h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: dir + "/buildpack.toml", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(descriptor))}))
+		_, err := tw.Write([]byte(descriptor))
+		h.AssertNil(t, err)
+		h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: dir + "/bin", Typeflag: tar.TypeDir, Mode: 0755}))
+		h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: dir + "/bin/build", Typeflag: tar.TypeReg, Mode: 0755, Size: int64(len("build"))}))
+		_, err = tw.Write([]byte("build"))
+		h.AssertNil(t, err)
+		h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: dir + "/bin/detect", Typeflag: tar.TypeLink, Linkname: dir + "/bin/build", Mode: 0755}))
+		h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: dir + "/bin/abs-link", Typeflag: tar.TypeSymlink, Linkname: dir + "/bin/build", Mode: 0777}))
+		h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: dir + "/bin/rel-link", Typeflag: tar.TypeSymlink, Linkname: "build", Mode: 0777}))
+		for _, hdr := range extra {
+			h.AssertNil(t, tw.WriteHeader(hdr))
+		}
+		h.AssertNil(t, tw.Close())
+		layer, err := tarball.LayerFromOpener(func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(buf.Bytes())), nil
+		})
+		h.AssertNil(t, err)
+		return layer
+	}
+
+	writeBuildpackage := func(dir string, pkgMD buildpack.BuildpackageMetadata, layers map[string]map[string]v1.Layer) {
+		img := empty.Image
+		layersMD := map[string]map[string]buildpack.BuildpackLayerInfo{}
+		for id, versions := range layers {
+			layersMD[id] = map[string]buildpack.BuildpackLayerInfo{}
+			for version, layer := range versions {
+				diffID, err := layer.DiffID()
+				h.AssertNil(t, err)
+				layersMD[id][version] = buildpack.BuildpackLayerInfo{API: "0.5", LayerDiffID: diffID.String()}
+				img, err = mutate.AppendLayers(img, layer)
+				h.AssertNil(t, err)
+			}
+		}
+		pkgLabel, err := json.Marshal(pkgMD)
+		h.AssertNil(t, err)
+		layersLabel, err := json.Marshal(layersMD)
+		h.AssertNil(t, err)
+		img, err = mutate.Config(img, v1.Config{Labels: map[string]string{
+			buildpack.BuildpackageMetadataLabel: string(pkgLabel),
+			buildpack.BuildpackLayersLabel:      string(layersLabel),
+		}})
+		h.AssertNil(t, err)
+		p, err := layout.Write(dir, empty.Index)
+		h.AssertNil(t, err)
+		h.AssertNil(t, p.AppendImage(img))
+	}
+
+	archiveDir := func(dir, path string) {
+		f, err := os.Create(path)
+		h.AssertNil(t, err)
+		defer f.Close()
+		tw := tar.NewWriter(f)
+		h.AssertNil(t, filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+			if err != nil || fi.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Typeflag: tar.TypeReg, Mode: 0644, Size: fi.Size()}); err != nil {
+				return err
+			}
+			contents, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			_, err = tw.Write(contents)
+			return err
+		}))
+		h.AssertNil(t, tw.Close())
+	}
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.package-store")
+		h.AssertNil(t, err)
+		extractDir = filepath.Join(tmpDir, "extracted")
+		layoutDir = filepath.Join(tmpDir, "layout")
+
+		writeBuildpackage(layoutDir, buildpack.BuildpackageMetadata{ID: "meta", Version: "1.0.0"}, map[string]map[string]v1.Layer{
+			"A": {
+				"1.0.0": buildpackLayer("A", "1.0.0", "[buildpack]\nid = \"A\"\nversion = \"1.0.0\"\n"),
+				"1.1.0": buildpackLayer("A", "1.1.0", "[buildpack]\nid = \"A\"\nversion = \"1.1.0\"\n"),
+			},
+			"meta": {
+				"1.0.0": buildpackLayer("meta", "1.0.0", "[buildpack]\nid = \"meta\"\nversion = \"1.0.0\"\n[[order]]\n[[order.group]]\nid = \"A\"\nversion = \"1.1.0\"\n"),
+			},
+		})
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#Lookup", func() {
+		it("extracts buildpacks from an OCI layout as they are looked up", func() {
+			store, err := buildpack.NewPackageBuildpackStore([]string{layoutDir}, extractDir)
+			h.AssertNil(t, err)
+			h.AssertPathDoesNotExist(t, extractDir)
+
+			bp, err := store.Lookup("A", "1.0.0")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.ConfigFile().Buildpack.Version, "1.0.0")
+			h.AssertEq(t, bp.ConfigFile().Dir, filepath.Join(extractDir, "A", "1.0.0"))
+			h.AssertPathExists(t, filepath.Join(extractDir, "A", "1.0.0", "buildpack.toml"))
+			h.AssertPathDoesNotExist(t, filepath.Join(extractDir, "A", "1.1.0"))
+			h.AssertPathDoesNotExist(t, filepath.Join(extractDir, "meta"))
+		})
+
+		it("relocates the targets of links below /cnb/buildpacks", func() {
+			store, err := buildpack.NewPackageBuildpackStore([]string{layoutDir}, extractDir)
+			h.AssertNil(t, err)
+
+			_, err = store.Lookup("A", "1.0.0")
+			h.AssertNil(t, err)
+
+			binDir := filepath.Join(extractDir, "A", "1.0.0", "bin")
+			build, err := os.Stat(filepath.Join(binDir, "build"))
+			h.AssertNil(t, err)
+			detect, err := os.Lstat(filepath.Join(binDir, "detect"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, os.SameFile(build, detect), true)
+
+			target, err := os.Readlink(filepath.Join(binDir, "abs-link"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, target, filepath.Join(binDir, "build"))
+			target, err = os.Readlink(filepath.Join(binDir, "rel-link"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, target, "build")
+		})
+
+		when("a hard link does not target an earlier file below /cnb/buildpacks", func() {
+			for _, target := range []string{
+				"/etc/passwd",
+				"/cnb/buildpacks/B/1.0.0/../../../../etc/passwd",
+				"../../etc/passwd",
+				"/cnb/buildpacks/B/1.0.0/bin/later",
+			} {
+				target := target
+
+				it("fails for target "+target, func() {
+					otherDir := filepath.Join(tmpDir, "other")
+					writeBuildpackage(otherDir, buildpack.BuildpackageMetadata{ID: "B", Version: "1.0.0"}, map[string]map[string]v1.Layer{
+						"B": {
+							"1.0.0": buildpackLayer("B", "1.0.0", "[buildpack]\nid = \"B\"\nversion = \"1.0.0\"\n",
+								&tar.Header{Name: "/cnb/buildpacks/B/1.0.0/bin/escape", Typeflag: tar.TypeLink, Linkname: target, Mode: 0644},
+								&tar.Header{Name: "/cnb/buildpacks/B/1.0.0/bin/later", Typeflag: tar.TypeReg, Mode: 0644},
+							),
+						},
+					})
+					store, err := buildpack.NewPackageBuildpackStore([]string{otherDir}, extractDir)
+					h.AssertNil(t, err)
+
+					_, err = store.Lookup("B", "1.0.0")
+					h.AssertError(t, err, "that is not an earlier file below /cnb/buildpacks")
+					h.AssertPathDoesNotExist(t, filepath.Join(extractDir, "B", "1.0.0", "bin", "escape"))
+				})
+			}
+		})
+
+		it("looks up meta-buildpacks", func() {
+			store, err := buildpack.NewPackageBuildpackStore([]string{layoutDir}, extractDir)
+			h.AssertNil(t, err)
+
+			bp, err := store.Lookup("meta", "1.0.0")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.ConfigFile().IsMetaBuildpack(), true)
+			h.AssertEq(t, bp.ConfigFile().Order, buildpack.Order{
+				{Group: []buildpack.GroupBuildpack{{ID: "A", Version: "1.1.0"}}},
+			})
+		})
+
+		it("resolves version ranges", func() {
+			store, err := buildpack.NewPackageBuildpackStore([]string{layoutDir}, extractDir)
+			h.AssertNil(t, err)
+
+			bp, err := store.Lookup("A", "^1.0")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.ConfigFile().Buildpack.Version, "1.1.0")
+		})
+
+		it("reads .cnb archives", func() {
+			cnbPath := filepath.Join(tmpDir, "meta.cnb")
+			archiveDir(layoutDir, cnbPath)
+
+			store, err := buildpack.NewPackageBuildpackStore([]string{cnbPath}, extractDir)
+			h.AssertNil(t, err)
+
+			bp, err := store.Lookup("A", "1.1.0")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.ConfigFile().Dir, filepath.Join(extractDir, "A", "1.1.0"))
+		})
+
+		it("fails if the buildpack is not in any buildpackage", func() {
+			store, err := buildpack.NewPackageBuildpackStore([]string{layoutDir}, extractDir)
+			h.AssertNil(t, err)
+
+			_, err = store.Lookup("B", "1.0.0")
+			h.AssertError(t, err, "buildpack 'B' not found in buildpackages")
+		})
+	})
+
+	when("the image is not a buildpackage", func() {
+		it("fails", func() {
+			otherDir := filepath.Join(tmpDir, "other")
+			p, err := layout.Write(otherDir, empty.Index)
+			h.AssertNil(t, err)
+			h.AssertNil(t, p.AppendImage(empty.Image))
+
+			_, err = buildpack.NewPackageBuildpackStore([]string{otherDir}, extractDir)
+			h.AssertError(t, err, "missing label 'io.buildpacks.buildpackage.metadata'")
+		})
+	})
+}
@@ -0,0 +1,353 @@
+// Package semver parses semantic versions and the version constraints that may be used
+// to reference buildpacks in an order and dependencies in a build plan.
+package semver
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var versionRegex = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+type Version struct {
+	Major,
+	Minor,
+	Patch uint64
+	Pre string
+}
+
+func MustParse(v string) *Version {
+	version, err := NewVersion(v)
+	if err != nil {
+		panic(err)
+	}
+	return version
+}
+
+// NewVersion parses a complete semantic version, such as 1.2.3 or 1.2.3-rc.1.
+// A leading "v" and build metadata are accepted and ignored.
+func NewVersion(v string) (*Version, error) {
+	matches := versionRegex.FindStringSubmatch(v)
+	if matches == nil {
+		return nil, errors.Errorf("could not parse '%s' as semantic version", v)
+	}
+	var nums [3]uint64
+	for i := range nums {
+		n, err := strconv.ParseUint(matches[i+1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing '%s'", matches[i+1])
+		}
+		nums[i] = n
+	}
+	return &Version{Major: nums[0], Minor: nums[1], Patch: nums[2], Pre: matches[4]}, nil
+}
+
+func (v *Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare returns one of the following results
+//   -1 is less than *Version o
+//    0 is equal to *Version o
+//    1 is greater than *Version o
+// Pre-release versions have lower precedence than the associated normal version.
+func (v *Version) Compare(o *Version) int {
+	if c := compareUint(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := compareUint(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+func (v *Version) sameCore(o *Version) bool {
+	return v.Major == o.Major && v.Minor == o.Minor && v.Patch == o.Patch
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func comparePre(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareUint(an, bn); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareUint(uint64(len(as)), uint64(len(bs)))
+}
+
+// A Constraint is a set of alternative version ranges separated by "||".
+// Each range is a list of comparisons separated by spaces or commas, all of which must be satisfied.
+// Supported comparisons are exact and partial versions (1.2.3, 1.2, 1.x), wildcards (*),
+// caret ranges (^1.2), tilde ranges (~1.2.3) and the operators =, >, >=, < and <=.
+type Constraint struct {
+	raw    string
+	ranges [][]comparison
+}
+
+type comparison struct {
+	op string
+	v  *Version
+}
+
+func MustParseConstraint(c string) *Constraint {
+	constraint, err := NewConstraint(c)
+	if err != nil {
+		panic(err)
+	}
+	return constraint
+}
+
+func NewConstraint(c string) (*Constraint, error) {
+	constraint := &Constraint{raw: c}
+	for _, alt := range strings.Split(c, "||") {
+		var rng []comparison
+		for _, term := range strings.FieldsFunc(alt, func(r rune) bool { return r == ' ' || r == ',' }) {
+			comparisons, err := parseTerm(term)
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not parse '%s' as version constraint", c)
+			}
+			rng = append(rng, comparisons...)
+		}
+		// an empty range matches every release, so it is only allowed as the whole constraint
+		if len(rng) == 0 && strings.TrimSpace(c) != "" {
+			return nil, errors.Errorf("could not parse '%s' as version constraint: empty alternative", c)
+		}
+		constraint.ranges = append(constraint.ranges, rng)
+	}
+	return constraint, nil
+}
+
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check returns true if the version satisfies the constraint.
+// Pre-release versions only satisfy a range that explicitly references a pre-release of the same version.
+func (c *Constraint) Check(v *Version) bool {
+	for _, rng := range c.ranges {
+		if checkRange(rng, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkRange(rng []comparison, v *Version) bool {
+	preAllowed := v.Pre == ""
+	for _, cmp := range rng {
+		if !cmp.check(v) {
+			return false
+		}
+		if cmp.v.Pre != "" && cmp.v.sameCore(v) {
+			preAllowed = true
+		}
+	}
+	return preAllowed
+}
+
+func (c comparison) check(v *Version) bool {
+	result := v.Compare(c.v)
+	switch c.op {
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	default:
+		return result == 0
+	}
+}
+
+// IsConstraint returns true if s is empty or uses range syntax (operators, wildcards or alternatives).
+// Any other string names a single version, even if it is not a semantic version.
+// It does not validate s, use NewConstraint to parse it.
+func IsConstraint(s string) bool {
+	if s == "" || strings.ContainsAny(s, "^~<>=*|, ") {
+		return true
+	}
+	core := strings.SplitN(strings.SplitN(s, "+", 2)[0], "-", 2)[0]
+	for _, f := range strings.Split(core, ".") {
+		if f == "x" || f == "X" {
+			return true
+		}
+	}
+	return false
+}
+
+var minVersion = &Version{}
+
+func parseTerm(term string) ([]comparison, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, prefix) {
+			op = prefix
+			term = strings.TrimPrefix(term, prefix)
+			break
+		}
+	}
+	p, err := parsePartial(term)
+	if err != nil {
+		return nil, err
+	}
+	if p.parts == 0 {
+		switch op {
+		case "", "=", ">=", "<=", "^", "~":
+			return []comparison{{op: ">=", v: minVersion}}, nil
+		default:
+			return nil, errors.Errorf("'%s%s' matches no versions", op, term)
+		}
+	}
+	lower := p.version()
+	switch op {
+	case "^":
+		return []comparison{{">=", lower}, {"<", p.caretUpper()}}, nil
+	case "~":
+		return []comparison{{">=", lower}, {"<", p.next(minInt(p.parts, 2))}}, nil
+	case ">":
+		if p.parts < 3 {
+			return []comparison{{">=", p.next(p.parts)}}, nil
+		}
+		return []comparison{{">", lower}}, nil
+	case ">=":
+		return []comparison{{">=", lower}}, nil
+	case "<":
+		return []comparison{{"<", lower}}, nil
+	case "<=":
+		if p.parts < 3 {
+			return []comparison{{"<", p.next(p.parts)}}, nil
+		}
+		return []comparison{{"<=", lower}}, nil
+	default:
+		if p.parts < 3 {
+			return []comparison{{">=", lower}, {"<", p.next(p.parts)}}, nil
+		}
+		return []comparison{{"=", lower}}, nil
+	}
+}
+
+// partial is a version where trailing components may be omitted or replaced by a wildcard (x, X or *).
+type partial struct {
+	nums  [3]uint64
+	parts int
+	pre   string
+}
+
+func parsePartial(s string) (partial, error) {
+	var p partial
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		p.pre = s[i+1:]
+		s = s[:i]
+	}
+	if s == "" {
+		return p, errors.New("missing version")
+	}
+	fields := strings.Split(s, ".")
+	if len(fields) > 3 {
+		return p, errors.Errorf("too many version components in '%s'", s)
+	}
+	wildcard := false
+	for i, f := range fields {
+		if f == "x" || f == "X" || f == "*" {
+			wildcard = true
+			continue
+		}
+		if wildcard {
+			return p, errors.Errorf("version component '%s' follows a wildcard", f)
+		}
+		n, err := strconv.ParseUint(f, 10, 64)
+		if err != nil {
+			return p, errors.Errorf("invalid version component '%s'", f)
+		}
+		p.nums[i] = n
+		p.parts = i + 1
+	}
+	if p.pre != "" && p.parts < 3 {
+		return p, errors.Errorf("pre-release '%s' requires a complete version", p.pre)
+	}
+	return p, nil
+}
+
+func (p partial) version() *Version {
+	return &Version{Major: p.nums[0], Minor: p.nums[1], Patch: p.nums[2], Pre: p.pre}
+}
+
+// next returns the version following p when incrementing the component at position n-1.
+func (p partial) next(n int) *Version {
+	v := &Version{}
+	switch n {
+	case 1:
+		v.Major = p.nums[0] + 1
+	case 2:
+		v.Major, v.Minor = p.nums[0], p.nums[1]+1
+	default:
+		v.Major, v.Minor, v.Patch = p.nums[0], p.nums[1], p.nums[2]+1
+	}
+	return v
+}
+
+// caretUpper returns the exclusive upper bound of a caret range, which allows changes
+// that do not modify the left-most non-zero component.
+func (p partial) caretUpper() *Version {
+	for i := 0; i < p.parts; i++ {
+		if p.nums[i] != 0 || i == p.parts-1 {
+			return p.next(i + 1)
+		}
+	}
+	return p.next(1)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
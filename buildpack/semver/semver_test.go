@@ -0,0 +1,105 @@
+package semver_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack/semver"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestSemver(t *testing.T) {
+	spec.Run(t, "Semver", testSemver, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSemver(t *testing.T, when spec.G, it spec.S) {
+	when("#NewVersion", func() {
+		it("parses complete versions", func() {
+			v, err := semver.NewVersion("v1.2.3-rc.1+build.5")
+			h.AssertNil(t, err)
+			h.AssertEq(t, v.String(), "1.2.3-rc.1")
+		})
+
+		it("rejects partial versions", func() {
+			_, err := semver.NewVersion("1.2")
+			h.AssertError(t, err, "could not parse '1.2' as semantic version")
+		})
+	})
+
+	when("#Compare", func() {
+		it("orders versions by precedence", func() {
+			ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.10.0", "2.0.0"}
+			for i := 1; i < len(ordered); i++ {
+				h.AssertEq(t, semver.MustParse(ordered[i-1]).Compare(semver.MustParse(ordered[i])), -1)
+				h.AssertEq(t, semver.MustParse(ordered[i]).Compare(semver.MustParse(ordered[i-1])), 1)
+			}
+			h.AssertEq(t, semver.MustParse("1.2.3").Compare(semver.MustParse("v1.2.3")), 0)
+		})
+	})
+
+	when("#Check", func() {
+		for _, tc := range []struct {
+			constraint string
+			matches    []string
+			misses     []string
+		}{
+			{"*", []string{"0.0.1", "1.2.3", "10.0.0"}, []string{"1.0.0-rc.1"}},
+			{"1.2.3", []string{"1.2.3"}, []string{"1.2.4", "1.2.3-rc.1"}},
+			{"1.2.x", []string{"1.2.0", "1.2.9"}, []string{"1.3.0", "1.1.9"}},
+			{"^1.2", []string{"1.2.0", "1.9.9"}, []string{"1.1.9", "2.0.0", "2.0.0-rc.1"}},
+			{"^0.2.3", []string{"0.2.3", "0.2.9"}, []string{"0.3.0", "0.2.2"}},
+			{"^0.0.3", []string{"0.0.3"}, []string{"0.0.4"}},
+			{"~2.0.3", []string{"2.0.3", "2.0.9"}, []string{"2.1.0", "2.0.2"}},
+			{"~1", []string{"1.0.0", "1.9.0"}, []string{"2.0.0"}},
+			{">1.2", []string{"1.3.0"}, []string{"1.2.9"}},
+			{"<=1.2", []string{"1.2.9", "0.1.0"}, []string{"1.3.0"}},
+			{">=1.0.0, <2.0.0", []string{"1.0.0", "1.5.0"}, []string{"0.9.0", "2.0.0"}},
+			{"^1.0.0 || ^3.0.0", []string{"1.1.0", "3.1.0"}, []string{"2.0.0"}},
+			{"^1.0.0-rc.1", []string{"1.0.0-rc.2", "1.0.0", "1.2.0"}, []string{"1.2.0-rc.1"}},
+		} {
+			tc := tc
+			it("checks versions against '"+tc.constraint+"'", func() {
+				c, err := semver.NewConstraint(tc.constraint)
+				h.AssertNil(t, err)
+				for _, v := range tc.matches {
+					if !c.Check(semver.MustParse(v)) {
+						t.Fatalf("expected '%s' to satisfy '%s'", v, tc.constraint)
+					}
+				}
+				for _, v := range tc.misses {
+					if c.Check(semver.MustParse(v)) {
+						t.Fatalf("expected '%s' not to satisfy '%s'", v, tc.constraint)
+					}
+				}
+			})
+		}
+
+		it("rejects invalid constraints", func() {
+			_, err := semver.NewConstraint("^1.x.3")
+			h.AssertError(t, err, "could not parse '^1.x.3' as version constraint")
+		})
+
+		it("rejects empty alternatives", func() {
+			for _, c := range []string{"^1.2 ||", "1.x || || 2.x", "|| 1.x"} {
+				_, err := semver.NewConstraint(c)
+				h.AssertError(t, err, "could not parse '"+c+"' as version constraint: empty alternative")
+			}
+		})
+	})
+
+	when("#IsConstraint", func() {
+		it("returns true for range syntax", func() {
+			for _, s := range []string{"", "*", "^1.2", "~2.0.3", ">=1", "1.x", "1 || 2"} {
+				h.AssertEq(t, semver.IsConstraint(s), true)
+			}
+		})
+
+		it("returns false for single versions", func() {
+			for _, s := range []string{"1.2.3", "v1", "1.2", "1.0.0-linux"} {
+				h.AssertEq(t, semver.IsConstraint(s), false)
+			}
+		})
+	})
+}
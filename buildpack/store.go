@@ -1,10 +1,14 @@
 package buildpack
 
 import (
+	"io/ioutil"
+	"os"
 	"path/filepath"
 
 	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
 
+	"github.com/buildpacks/lifecycle/buildpack/semver"
 	"github.com/buildpacks/lifecycle/launch"
 )
 
@@ -27,7 +31,17 @@ func NewBuildpackStore(dir string) (*DirBuildpackStore, error) {
 	return &DirBuildpackStore{Dir: dir}, nil
 }
 
+// Lookup returns the buildpack with the given ID and version.
+// If bpVersion is a version range (see semver.IsConstraint), the highest version in the store
+// satisfying the range is returned, and its descriptor reports the exact version that was chosen.
 func (f *DirBuildpackStore) Lookup(bpID, bpVersion string) (Buildpack, error) {
+	if semver.IsConstraint(bpVersion) {
+		version, err := f.resolve(bpID, bpVersion)
+		if err != nil {
+			return nil, err
+		}
+		bpVersion = version
+	}
 	bpTOML := Descriptor{}
 	bpPath := filepath.Join(f.Dir, launch.EscapeID(bpID), bpVersion)
 	tomlPath := filepath.Join(bpPath, "buildpack.toml")
@@ -37,3 +51,38 @@ func (f *DirBuildpackStore) Lookup(bpID, bpVersion string) (Buildpack, error) {
 	bpTOML.Dir = bpPath
 	return &bpTOML, nil
 }
+
+func (f *DirBuildpackStore) resolve(bpID, bpVersion string) (string, error) {
+	constraint, err := semver.NewConstraint(bpVersion)
+	if err != nil {
+		return "", errors.Wrapf(err, "buildpack '%s'", bpID)
+	}
+	fis, err := ioutil.ReadDir(filepath.Join(f.Dir, launch.EscapeID(bpID)))
+	if err != nil {
+		return "", errors.Wrapf(err, "listing versions of buildpack '%s'", bpID)
+	}
+	var (
+		best     *semver.Version
+		bestName string
+	)
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		v, err := semver.NewVersion(fi.Name())
+		if err != nil || !constraint.Check(v) {
+			continue
+		}
+		if best != nil && v.Compare(best) <= 0 {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(f.Dir, launch.EscapeID(bpID), fi.Name(), "buildpack.toml")); err != nil {
+			continue
+		}
+		best, bestName = v, fi.Name()
+	}
+	if best == nil {
+		return "", errors.Errorf("no version of buildpack '%s' satisfies '%s'", bpID, bpVersion)
+	}
+	return bestName, nil
+}
@@ -0,0 +1,85 @@
+package buildpack_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestStore(t *testing.T) {
+	spec.Run(t, "Store", testStore, spec.Report(report.Terminal{}))
+}
+
+func testStore(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir string
+		store  *buildpack.DirBuildpackStore
+	)
+
+	writeBuildpack := func(id, version string) {
+		h.Mkdir(t, filepath.Join(tmpDir, id, version))
+		h.Mkfile(t, "[buildpack]\nid = \""+id+"\"\nversion = \""+version+"\"\n",
+			filepath.Join(tmpDir, id, version, "buildpack.toml"))
+	}
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.store")
+		h.AssertNil(t, err)
+		for _, version := range []string{"1.0.0", "1.2.0", "1.10.1", "2.0.0", "2.1.0-rc.1"} {
+			writeBuildpack("A", version)
+		}
+		h.Mkdir(t, filepath.Join(tmpDir, "A", "1.11.0"))
+		store, err = buildpack.NewBuildpackStore(tmpDir)
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#Lookup", func() {
+		it("returns the buildpack with the exact version", func() {
+			bp, err := store.Lookup("A", "1.2.0")
+			h.AssertNil(t, err)
+			h.AssertEq(t, bp.ConfigFile().Buildpack.Version, "1.2.0")
+			h.AssertEq(t, bp.ConfigFile().Dir, filepath.Join(tmpDir, "A", "1.2.0"))
+		})
+
+		when("the version is a range", func() {
+			it("returns the highest matching version containing a buildpack.toml", func() {
+				bp, err := store.Lookup("A", "^1.0")
+				h.AssertNil(t, err)
+				h.AssertEq(t, bp.ConfigFile().Buildpack.Version, "1.10.1")
+				h.AssertEq(t, bp.ConfigFile().Dir, filepath.Join(tmpDir, "A", "1.10.1"))
+			})
+
+			it("ignores pre-releases unless requested", func() {
+				bp, err := store.Lookup("A", "*")
+				h.AssertNil(t, err)
+				h.AssertEq(t, bp.ConfigFile().Buildpack.Version, "2.0.0")
+
+				bp, err = store.Lookup("A", ">=2.1.0-rc.1")
+				h.AssertNil(t, err)
+				h.AssertEq(t, bp.ConfigFile().Buildpack.Version, "2.1.0-rc.1")
+			})
+
+			it("fails if no version matches", func() {
+				_, err := store.Lookup("A", "^3")
+				h.AssertError(t, err, "no version of buildpack 'A' satisfies '^3'")
+			})
+
+			it("fails if the range is invalid", func() {
+				_, err := store.Lookup("A", "^1.x.0")
+				h.AssertError(t, err, "could not parse '^1.x.0' as version constraint")
+			})
+		})
+	})
+}
@@ -44,6 +44,10 @@ if exist layers-%bp_id%-%bp_version% (
   xcopy /e /q layers-%bp_id%-%bp_version% %layers_dir% >nul
 )
 
+if exist build-out-%bp_id%-%bp_version% (
+  type build-out-%bp_id%-%bp_version%
+)
+
 if exist build-status-%bp_id%-%bp_version% (
   for /f "tokens=* USEBACKQ" %%F in (`type build-status-%bp_id%-%bp_version%`) do (
     exit /b %%F
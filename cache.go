@@ -15,7 +15,7 @@ func (e *Exporter) Cache(layersDir string, cacheStore Cache) error {
 	if err != nil {
 		return errors.Wrap(err, "metadata for previous cache")
 	}
-	meta := platform.CacheMetadata{}
+	meta := platform.CacheMetadata{Detect: e.DetectCache}
 
 	for _, bp := range e.Buildpacks {
 		bpDir, err := readBuildpackLayersDir(layersDir, bp, e.Logger)
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/buildpacks/imgutil"
 	"github.com/pkg/errors"
@@ -14,13 +15,34 @@ import (
 type cachingImage struct {
 	imgutil.Image
 	cache *VolumeCache
+	// fromCache holds the layers that were reused from the cache
+	fromCache map[string]bool
+}
+
+// createdTimeImage is implemented by images whose created time may be set.
+type createdTimeImage interface {
+	SetCreatedTime(t time.Time) error
+}
+
+// createdTimeCachingImage is a cachingImage of an image whose created time may be set.
+type createdTimeCachingImage struct {
+	*cachingImage
 }
 
 func NewCachingImage(image imgutil.Image, cache *VolumeCache) imgutil.Image {
-	return &cachingImage{
-		Image: image,
-		cache: cache,
+	c := &cachingImage{
+		Image:     image,
+		cache:     cache,
+		fromCache: map[string]bool{},
+	}
+	if _, ok := image.(createdTimeImage); ok {
+		return &createdTimeCachingImage{c}
 	}
+	return c
+}
+
+func (c *createdTimeCachingImage) SetCreatedTime(t time.Time) error {
+	return c.Image.(createdTimeImage).SetCreatedTime(t)
 }
 
 func (c *cachingImage) AddLayer(path string) error {
@@ -60,7 +82,11 @@ func (c *cachingImage) ReuseLayer(diffID string) error {
 		if err != nil {
 			return err
 		}
-		return c.Image.AddLayerWithDiffID(path, diffID)
+		if err := c.Image.AddLayerWithDiffID(path, diffID); err != nil {
+			return err
+		}
+		c.fromCache[diffID] = true
+		return nil
 	}
 
 	if err := c.Image.ReuseLayer(diffID); err != nil {
@@ -73,6 +99,11 @@ func (c *cachingImage) ReuseLayer(diffID string) error {
 	return c.cache.AddLayer(rc, diffID)
 }
 
+// ReusedFromCache returns true if the layer with diffID was reused from the cache rather than the previous image.
+func (c *cachingImage) ReusedFromCache(diffID string) bool {
+	return c.fromCache[diffID]
+}
+
 func (c *cachingImage) GetLayer(diffID string) (io.ReadCloser, error) {
 	if found, err := c.cache.HasLayer(diffID); err != nil {
 		return nil, fmt.Errorf("layer with SHA '%s' not found", diffID)
@@ -13,10 +13,12 @@ import (
 
 	"github.com/buildpacks/imgutil"
 	"github.com/buildpacks/imgutil/fakes"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
 	"github.com/buildpacks/lifecycle/cache"
+	"github.com/buildpacks/lifecycle/image"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 )
 
@@ -100,6 +102,12 @@ func testCachingImage(t *testing.T, when spec.G, it spec.S) {
 				_, err := volumeCache.RetrieveLayerFile(layerSHA)
 				h.AssertNil(t, err)
 			})
+
+			it("reports the layer as reused from the cache", func() {
+				h.AssertNil(t, subject.ReuseLayer(layerSHA))
+
+				h.AssertEq(t, reusedFromCache(subject, layerSHA), true)
+			})
 		})
 
 		when("the layer does not exist in the cache", func() {
@@ -128,6 +136,12 @@ func testCachingImage(t *testing.T, when spec.G, it spec.S) {
 				_, err := volumeCache.RetrieveLayer(layerSHA)
 				h.AssertNil(t, err)
 			})
+
+			it("does not report the layer as reused from the cache", func() {
+				h.AssertNil(t, subject.ReuseLayer(layerSHA))
+
+				h.AssertEq(t, reusedFromCache(subject, layerSHA), false)
+			})
 		})
 	})
 
@@ -165,4 +179,40 @@ func testCachingImage(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 	})
+
+	when("#SetCreatedTime", func() {
+		it("is not supported when the image does not support it", func() {
+			_, ok := subject.(createdTimeImage)
+			h.AssertEq(t, ok, false)
+		})
+
+		when("the image supports it", func() {
+			it("sets the created time of the image", func() {
+				output := image.Output{Format: image.OutputOCI, Path: filepath.Join(tmpDir, "layout")}
+				base, err := random.Image(64, 1)
+				h.AssertNil(t, err)
+				fileImage, err := image.NewFileImage("some/app", output, base, nil)
+				h.AssertNil(t, err)
+				subject = cache.NewCachingImage(fileImage, volumeCache)
+
+				created := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+				img, ok := subject.(createdTimeImage)
+				h.AssertEq(t, ok, true)
+				h.AssertNil(t, img.SetCreatedTime(created))
+				h.AssertNil(t, subject.Save())
+
+				createdAt, err := fileImage.CreatedAt()
+				h.AssertNil(t, err)
+				h.AssertEq(t, createdAt.Equal(created), true)
+			})
+		})
+	})
+}
+
+type createdTimeImage interface {
+	SetCreatedTime(t time.Time) error
+}
+
+func reusedFromCache(image imgutil.Image, diffID string) bool {
+	return image.(interface{ ReusedFromCache(string) bool }).ReusedFromCache(diffID)
 }
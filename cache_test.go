@@ -22,6 +22,7 @@ import (
 	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/cache"
 	"github.com/buildpacks/lifecycle/layers"
+	"github.com/buildpacks/lifecycle/platform"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 	"github.com/buildpacks/lifecycle/testmock"
 )
@@ -113,6 +114,18 @@ func testCache(t *testing.T, when spec.G, it spec.S) {
 					})
 				})
 
+				it("stores detect cache entries in the cache metadata", func() {
+					exporter.DetectCache = []platform.DetectCacheEntry{
+						{ID: "buildpack.id", Version: "1.2.3", Key: "some-key", Code: 100},
+					}
+					err := exporter.Cache(layersDir, testCache)
+					h.AssertNil(t, err)
+
+					metadata, err := testCache.RetrieveMetadata()
+					h.AssertNil(t, err)
+					h.AssertEq(t, metadata.Detect, exporter.DetectCache)
+				})
+
 				it("doesn't export uncached layers", func() {
 					err := exporter.Cache(layersDir, testCache)
 					h.AssertNil(t, err)
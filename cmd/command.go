@@ -4,6 +4,8 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 // Command defines the interface for running the lifecycle phases
@@ -24,12 +26,14 @@ type Command interface {
 func Run(c Command, asSubcommand bool) {
 	var (
 		printVersion bool
+		logFormat    string
 		logLevel     string
 		noColor      bool
 	)
 
 	log.SetOutput(ioutil.Discard)
 	FlagVersion(&printVersion)
+	FlagLogFormat(&logFormat)
 	FlagLogLevel(&logLevel)
 	FlagNoColor(&noColor)
 	c.DefineFlags()
@@ -49,6 +53,13 @@ func Run(c Command, asSubcommand bool) {
 	if printVersion {
 		ExitWithVersion()
 	}
+	phase := filepath.Base(os.Args[0])
+	if asSubcommand {
+		phase = os.Args[1]
+	}
+	if err := SetLogFormat(logFormat, strings.TrimSuffix(phase, filepath.Ext(phase))); err != nil {
+		Exit(err)
+	}
 	if err := SetLogLevel(logLevel); err != nil {
 		Exit(err)
 	}
@@ -23,16 +23,18 @@ const (
 type LifecycleExitError int
 
 const (
-	FailedDetect           LifecycleExitError = iota
-	FailedDetectWithErrors                    // no buildpacks detected
-	DetectError                               // no buildpacks detected and at least one errored
-	AnalyzeError                              // generic analyze error
-	RestoreError                              // generic restore error
-	FailedBuildWithErrors                     // buildpack error during /bin/build
-	BuildError                                // generic build error
-	ExportError                               // generic export error
-	RebaseError                               // generic rebase error
-	LaunchError                               // generic launch error
+	FailedDetect            LifecycleExitError = iota
+	FailedDetectWithErrors                     // no buildpacks detected
+	DetectError                                // no buildpacks detected and at least one errored
+	FailedDetectWithTimeout                    // no buildpacks detected and at least one timed out
+	AnalyzeError                               // generic analyze error
+	RestoreError                               // generic restore error
+	FailedBuildWithErrors                      // buildpack error during /bin/build
+	FailedBuildWithTimeout                     // buildpack timed out during /bin/build
+	BuildError                                 // generic build error
+	ExportError                                // generic export error
+	RebaseError                                // generic rebase error
+	LaunchError                                // generic launch error
 )
 
 type Platform interface {
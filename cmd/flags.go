@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
+	"time"
 
 	"github.com/buildpacks/lifecycle/api"
 )
@@ -16,6 +18,7 @@ var (
 	DefaultDeprecationMode = DeprecationModeWarn
 	DefaultLauncherPath    = filepath.Join(rootDir, "cnb", "lifecycle", "launcher"+execExt)
 	DefaultLayersDir       = filepath.Join(rootDir, "layers")
+	DefaultLogFormat       = LogFormatText
 	DefaultLogLevel        = "info"
 	DefaultPlatformAPI     = "0.3"
 	DefaultPlatformDir     = filepath.Join(rootDir, "platform")
@@ -23,6 +26,10 @@ var (
 	DefaultStackPath       = filepath.Join(rootDir, "cnb", "stack.toml")
 
 	DefaultAnalyzedFile        = "analyzed.toml"
+	DefaultBuildCheckpointFile = "build-checkpoint.toml"
+	DefaultBuildReportFile     = "build-report.toml"
+	DefaultDetectCacheFile     = "detect-cache.json"
+	DefaultDetectReportFile    = "detect-report.json"
 	DefaultGroupFile           = "group.toml"
 	DefaultOrderFile           = "order.toml"
 	DefaultPlanFile            = "plan.toml"
@@ -30,6 +37,7 @@ var (
 	DefaultReportFile          = "report.toml"
 
 	PlaceholderAnalyzedPath        = filepath.Join("<layers>", DefaultAnalyzedFile)
+	PlaceholderDetectReportPath    = filepath.Join("<layers>", DefaultDetectReportFile)
 	PlaceholderGroupPath           = filepath.Join("<layers>", DefaultGroupFile)
 	PlaceholderPlanPath            = filepath.Join("<layers>", DefaultPlanFile)
 	PlaceholderProjectMetadataPath = filepath.Join("<layers>", DefaultProjectMetadataFile)
@@ -40,28 +48,48 @@ var (
 const (
 	EnvAnalyzedPath        = "CNB_ANALYZED_PATH"
 	EnvAppDir              = "CNB_APP_DIR"
+	EnvBuildTimeout        = "CNB_BUILD_TIMEOUT"
+	EnvBuildpackages       = "CNB_BUILDPACKAGES"
 	EnvBuildpacksDir       = "CNB_BUILDPACKS_DIR"
 	EnvCacheDir            = "CNB_CACHE_DIR"
 	EnvCacheImage          = "CNB_CACHE_IMAGE"
+	EnvCreatedTime         = "CNB_CREATED_TIME"
 	EnvDeprecationMode     = "CNB_DEPRECATION_MODE"
+	EnvDetectCache         = "CNB_DETECT_CACHE" // defaults to false
+	EnvDetectParallelism   = "CNB_DETECT_PARALLELISM"
+	EnvDetectReportPath    = "CNB_DETECT_REPORT_PATH"
+	EnvDetectTimeout       = "CNB_DETECT_TIMEOUT"
+	EnvExportParallelism   = "CNB_EXPORT_PARALLELISM"
 	EnvGID                 = "CNB_GROUP_ID"
 	EnvGroupPath           = "CNB_GROUP_PATH"
+	EnvGroupPinPath        = "CNB_GROUP_PIN_PATH"
 	EnvLaunchCacheDir      = "CNB_LAUNCH_CACHE_DIR"
+	EnvLayerCreatedTime    = "CNB_LAYER_CREATED_TIME" // defaults to false
 	EnvLayersDir           = "CNB_LAYERS_DIR"
+	EnvLogFormat           = "CNB_LOG_FORMAT"
+	EnvLogPrefix           = "CNB_LOG_PREFIX"
 	EnvLogLevel            = "CNB_LOG_LEVEL"
 	EnvNoColor             = "CNB_NO_COLOR" // defaults to false
+	EnvOffline             = "CNB_OFFLINE"  // defaults to false
 	EnvOrderPath           = "CNB_ORDER_PATH"
+	EnvOutput              = "CNB_OUTPUT"
 	EnvPlanPath            = "CNB_PLAN_PATH"
+	EnvPlanVersions        = "CNB_PLAN_VERSIONS" // defaults to false
 	EnvPlatformAPI         = "CNB_PLATFORM_API"
 	EnvPlatformDir         = "CNB_PLATFORM_DIR"
 	EnvPreviousImage       = "CNB_PREVIOUS_IMAGE"
 	EnvProcessType         = "CNB_PROCESS_TYPE"
 	EnvProjectMetadataPath = "CNB_PROJECT_METADATA_PATH"
+	EnvProtectedAppPaths   = "CNB_PROTECTED_APP_PATHS"
 	EnvReportPath          = "CNB_REPORT_PATH"
+	EnvResume              = "CNB_RESUME" // defaults to false
 	EnvRunImage            = "CNB_RUN_IMAGE"
+	EnvSBOM                = "CNB_SBOM"                // defaults to false
 	EnvSkipLayers          = "CNB_ANALYZE_SKIP_LAYERS" // defaults to false
 	EnvSkipRestore         = "CNB_SKIP_RESTORE"        // defaults to false
+	EnvSourceDateEpoch     = "SOURCE_DATE_EPOCH"
 	EnvStackPath           = "CNB_STACK_PATH"
+	EnvStrict              = "CNB_STRICT" // defaults to false
 	EnvUID                 = "CNB_USER_ID"
 	EnvUseDaemon           = "CNB_USE_DAEMON" // defaults to false
 )
@@ -80,6 +108,14 @@ func FlagAppDir(appDir *string) {
 	flagSet.StringVar(appDir, "app", EnvOrDefault(EnvAppDir, DefaultAppDir), "path to app directory")
 }
 
+func FlagBuildTimeout(timeout *time.Duration) {
+	flagSet.DurationVar(timeout, "build-timeout", durationEnv(EnvBuildTimeout), "maximum duration of each buildpack's /bin/build (0 for no limit)")
+}
+
+func FlagBuildpackages(buildpackages *string) {
+	flagSet.StringVar(buildpackages, "buildpackages", os.Getenv(EnvBuildpackages), "list of buildpackage archives or OCI layout directories to use instead of the buildpacks directory")
+}
+
 func FlagBuildpacksDir(buildpacksDir *string) {
 	flagSet.StringVar(buildpacksDir, "buildpacks", EnvOrDefault(EnvBuildpacksDir, DefaultBuildpacksDir), "path to buildpacks directory")
 }
@@ -92,6 +128,34 @@ func FlagCacheImage(cacheImage *string) {
 	flagSet.StringVar(cacheImage, "cache-image", os.Getenv(EnvCacheImage), "cache image tag name")
 }
 
+func FlagCreatedTime(createdTime *string) {
+	flagSet.StringVar(createdTime, "created-time", EnvOrDefault(EnvCreatedTime, os.Getenv(EnvSourceDateEpoch)), "image created time, as seconds since the Unix epoch or RFC 3339 (defaults to $SOURCE_DATE_EPOCH)")
+}
+
+func FlagDetectCache(detectCache *bool) {
+	flagSet.BoolVar(detectCache, "detect-cache", BoolEnv(EnvDetectCache), "reuse detect results stored in the cache when buildpack inputs are unchanged")
+}
+
+func FlagDetectParallelism(parallelism *int) {
+	flagSet.IntVar(parallelism, "detect-parallelism", intEnv(EnvDetectParallelism), "maximum number of buildpacks to detect concurrently (0 for no limit)")
+}
+
+func FlagDetectReportPath(detectReportPath *string) {
+	flagSet.StringVar(detectReportPath, "detect-report", EnvOrDefault(EnvDetectReportPath, PlaceholderDetectReportPath), "path to detect-report.json")
+}
+
+func DefaultDetectReportPath(platformAPI, layersDir string) string {
+	return defaultPath(DefaultDetectReportFile, platformAPI, layersDir)
+}
+
+func FlagDetectTimeout(timeout *time.Duration) {
+	flagSet.DurationVar(timeout, "detect-timeout", durationEnv(EnvDetectTimeout), "maximum duration of each buildpack's /bin/detect (0 for no limit)")
+}
+
+func FlagExportParallelism(parallelism *int) {
+	flagSet.IntVar(parallelism, "export-parallelism", intEnvOrDefault(EnvExportParallelism, runtime.NumCPU()), "maximum number of layers to create concurrently during export (0 for no limit, defaults to the number of CPUs)")
+}
+
 func FlagGID(gid *int) {
 	flagSet.IntVar(gid, "gid", intEnv(EnvGID), "GID of user's group in the stack's build and run images")
 }
@@ -100,6 +164,10 @@ func FlagGroupPath(groupPath *string) {
 	flagSet.StringVar(groupPath, "group", EnvOrDefault(EnvGroupPath, PlaceholderGroupPath), "path to group.toml")
 }
 
+func FlagGroupPinPath(groupPinPath *string) {
+	flagSet.StringVar(groupPinPath, "group-pin", os.Getenv(EnvGroupPinPath), "path to a group.toml to detect instead of traversing the order")
+}
+
 func DefaultGroupPath(platformAPI, layersDir string) string {
 	return defaultPath(DefaultGroupFile, platformAPI, layersDir)
 }
@@ -112,6 +180,10 @@ func FlagLauncherPath(launcherPath *string) {
 	flagSet.StringVar(launcherPath, "launcher", DefaultLauncherPath, "path to launcher binary")
 }
 
+func FlagLayerCreatedTime(layerCreatedTime *bool) {
+	flagSet.BoolVar(layerCreatedTime, "layer-created-time", BoolEnv(EnvLayerCreatedTime), "use the image created time as the modification time of files in app and buildpack layers")
+}
+
 func FlagLayersDir(layersDir *string) {
 	flagSet.StringVar(layersDir, "layers", EnvOrDefault(EnvLayersDir, DefaultLayersDir), "path to layers directory")
 }
@@ -120,10 +192,22 @@ func FlagNoColor(skip *bool) {
 	flagSet.BoolVar(skip, "no-color", BoolEnv(EnvNoColor), "disable color output")
 }
 
+func FlagOCILabels(labels *StringSlice) {
+	flagSet.Var(labels, "oci-label", "org.opencontainers.image label to set, as '<name>=<value>' where name is one of created, revision, source, title or version (may be repeated)")
+}
+
+func FlagOffline(offline *bool) {
+	flagSet.BoolVar(offline, "offline", BoolEnv(EnvOffline), "run each buildpack's /bin/detect and /bin/build without network access")
+}
+
 func FlagOrderPath(orderPath *string) {
 	flagSet.StringVar(orderPath, "order", EnvOrDefault(EnvOrderPath, PlaceholderOrderPath), "path to order.toml")
 }
 
+func FlagOutput(output *string) {
+	flagSet.StringVar(output, "output", os.Getenv(EnvOutput), "write the image to 'oci:<path>' (an OCI image layout) or 'docker-archive:<path>' instead of a registry")
+}
+
 func DefaultOrderPath(platformAPI, layersDir string) string {
 	cnbOrderPath := filepath.Join(rootDir, "cnb", "order.toml")
 
@@ -144,6 +228,10 @@ func FlagPlanPath(planPath *string) {
 	flagSet.StringVar(planPath, "plan", EnvOrDefault(EnvPlanPath, PlaceholderPlanPath), "path to plan.toml")
 }
 
+func FlagPlanVersions(versions *bool) {
+	flagSet.BoolVar(versions, "plan-versions", BoolEnv(EnvPlanVersions), "match required dependency versions as semantic version constraints against provided versions")
+}
+
 func DefaultPlanPath(platformAPI, layersDir string) string {
 	return defaultPath(DefaultPlanFile, platformAPI, layersDir)
 }
@@ -156,6 +244,10 @@ func FlagPreviousImage(image *string) {
 	flagSet.StringVar(image, "previous-image", os.Getenv(EnvPreviousImage), "reference to previous image")
 }
 
+func FlagProtectedAppPaths(protectedAppPaths *string) {
+	flagSet.StringVar(protectedAppPaths, "protected-app-paths", os.Getenv(EnvProtectedAppPaths), "comma separated globs of paths in the app directory that buildpacks must not change")
+}
+
 func FlagReportPath(reportPath *string) {
 	flagSet.StringVar(reportPath, "report", EnvOrDefault(EnvReportPath, PlaceholderReportPath), "path to report.toml")
 }
@@ -164,10 +256,18 @@ func DefaultReportPath(platformAPI, layersDir string) string {
 	return defaultPath(DefaultReportFile, platformAPI, layersDir)
 }
 
+func FlagResume(resume *bool) {
+	flagSet.BoolVar(resume, "resume", BoolEnv(EnvResume), "checkpoint after each buildpack and skip buildpacks that completed a previous build with unchanged layers and app directory")
+}
+
 func FlagRunImage(runImage *string) {
 	flagSet.StringVar(runImage, "run-image", os.Getenv(EnvRunImage), "reference to run image")
 }
 
+func FlagSBOM(sbom *bool) {
+	flagSet.BoolVar(sbom, "sbom", BoolEnv(EnvSBOM), "export SPDX and CycloneDX documents converted from the bill of materials")
+}
+
 func FlagSkipLayers(skip *bool) {
 	flagSet.BoolVar(skip, "skip-layers", BoolEnv(EnvSkipLayers), "do not provide layer metadata to buildpacks")
 }
@@ -180,6 +280,10 @@ func FlagStackPath(stackPath *string) {
 	flagSet.StringVar(stackPath, "stack", EnvOrDefault(EnvStackPath, DefaultStackPath), "path to stack.toml")
 }
 
+func FlagStrict(strict *bool) {
+	flagSet.BoolVar(strict, "strict", BoolEnv(EnvStrict), "fail the build when a buildpack violates the layer contract")
+}
+
 func FlagTags(tags *StringSlice) {
 	flagSet.Var(tags, "tag", "additional tags")
 }
@@ -196,6 +300,14 @@ func FlagVersion(version *bool) {
 	flagSet.BoolVar(version, "version", false, "show version")
 }
 
+func FlagLogFormat(format *string) {
+	flagSet.StringVar(format, "log-format", EnvOrDefault(EnvLogFormat, DefaultLogFormat), "logging format, 'text' or 'json'")
+}
+
+func FlagLogPrefix(prefix *string) {
+	flagSet.StringVar(prefix, "log-prefix", os.Getenv(EnvLogPrefix), "prefix of each line of buildpack output, 'none', 'buildpack' or 'time'")
+}
+
 func FlagLogLevel(level *string) {
 	flagSet.StringVar(level, "log-level", EnvOrDefault(EnvLogLevel, DefaultLogLevel), "logging level")
 }
@@ -236,6 +348,22 @@ func intEnv(k string) int {
 	return d
 }
 
+func intEnvOrDefault(k string, defaultVal int) int {
+	if os.Getenv(k) == "" {
+		return defaultVal
+	}
+	return intEnv(k)
+}
+
+func durationEnv(k string) time.Duration {
+	v := os.Getenv(k)
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
 func BoolEnv(k string) bool {
 	v := os.Getenv(k)
 	b, err := strconv.ParseBool(v)
@@ -245,6 +373,18 @@ func BoolEnv(k string) bool {
 	return b
 }
 
+// ParseCreatedTime parses a time given as seconds since the Unix epoch, like SOURCE_DATE_EPOCH, or in RFC 3339 format.
+func ParseCreatedTime(s string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s', must be seconds since the Unix epoch or RFC 3339", s)
+	}
+	return t.UTC(), nil
+}
+
 func EnvOrDefault(key string, defaultVal string) string {
 	if envVal := os.Getenv(key); envVal != "" {
 		return envVal
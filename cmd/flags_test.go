@@ -0,0 +1,37 @@
+package cmd_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/cmd"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestFlags(t *testing.T) {
+	spec.Run(t, "Flags", testFlags, spec.Report(report.Terminal{}))
+}
+
+func testFlags(t *testing.T, when spec.G, it spec.S) {
+	when("#ParseCreatedTime", func() {
+		it("parses seconds since the Unix epoch", func() {
+			created, err := cmd.ParseCreatedTime("1600000000")
+			h.AssertNil(t, err)
+			h.AssertEq(t, created, time.Date(2020, time.September, 13, 12, 26, 40, 0, time.UTC))
+		})
+
+		it("parses RFC 3339 times as UTC", func() {
+			created, err := cmd.ParseCreatedTime("2020-09-13T14:26:40+02:00")
+			h.AssertNil(t, err)
+			h.AssertEq(t, created, time.Date(2020, time.September, 13, 12, 26, 40, 0, time.UTC))
+		})
+
+		it("fails for other formats", func() {
+			_, err := cmd.ParseCreatedTime("yesterday")
+			h.AssertError(t, err, "invalid time 'yesterday'")
+		})
+	})
+}
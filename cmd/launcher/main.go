@@ -21,6 +21,9 @@ func main() {
 
 func runLaunch() error {
 	color.Disable(cmd.BoolEnv(cmd.EnvNoColor))
+	if err := cmd.SetLogFormat(cmd.EnvOrDefault(cmd.EnvLogFormat, cmd.DefaultLogFormat), "launcher"); err != nil {
+		cmd.Exit(err)
+	}
 
 	platformAPI := cmd.EnvOrDefault(cmd.EnvPlatformAPI, cmd.DefaultPlatformAPI)
 	if err := cmd.VerifyPlatformAPI(platformAPI); err != nil {
@@ -56,6 +59,13 @@ func runLaunch() error {
 		Setenv:             os.Setenv,
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "-explain-env" {
+		if err := launcher.ExplainEnv(os.Stdout, os.Args[2:]); err != nil {
+			return cmd.FailErrCode(err, platform.CodeFor(cmd.LaunchError), "explain env")
+		}
+		return nil
+	}
+
 	if err := launcher.Launch(os.Args[0], os.Args[1:]); err != nil {
 		return cmd.FailErrCode(err, platform.CodeFor(cmd.LaunchError), "launch")
 	}
@@ -2,6 +2,10 @@ package main
 
 import (
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 
@@ -18,26 +22,41 @@ type buildCmd struct {
 	// flags: inputs
 	groupPath string
 	planPath  string
+	logPrefix string
 	buildArgs
 }
 
 type buildArgs struct {
 	// inputs needed when run by creator
-	buildpacksDir string
-	layersDir     string
-	appDir        string
-	platformDir   string
+	buildpacksDir     string
+	buildpackages     string
+	layersDir         string
+	appDir            string
+	logPrefixType     buildpack.LogPrefix
+	offline           bool
+	platformDir       string
+	protectedAppPaths string
+	resume            bool
+	strict            bool
+	timeout           time.Duration
 
 	platform cmd.Platform
 }
 
 func (b *buildCmd) DefineFlags() {
 	cmd.FlagBuildpacksDir(&b.buildpacksDir)
+	cmd.FlagBuildpackages(&b.buildpackages)
 	cmd.FlagGroupPath(&b.groupPath)
 	cmd.FlagPlanPath(&b.planPath)
 	cmd.FlagLayersDir(&b.layersDir)
 	cmd.FlagAppDir(&b.appDir)
 	cmd.FlagPlatformDir(&b.platformDir)
+	cmd.FlagProtectedAppPaths(&b.protectedAppPaths)
+	cmd.FlagBuildTimeout(&b.timeout)
+	cmd.FlagLogPrefix(&b.logPrefix)
+	cmd.FlagOffline(&b.offline)
+	cmd.FlagResume(&b.resume)
+	cmd.FlagStrict(&b.strict)
 }
 
 func (b *buildCmd) Args(nargs int, args []string) error {
@@ -53,6 +72,11 @@ func (b *buildCmd) Args(nargs int, args []string) error {
 		b.planPath = cmd.DefaultPlanPath(b.platform.API(), b.layersDir)
 	}
 
+	var err error
+	if b.logPrefixType, err = buildpack.ParseLogPrefix(b.logPrefix); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse log prefix")
+	}
+
 	return nil
 }
 
@@ -76,30 +100,49 @@ func (b *buildCmd) Exec() error {
 }
 
 func (ba buildArgs) build(group buildpack.Group, plan platform.BuildPlan) error {
-	buildpackStore, err := buildpack.NewBuildpackStore(ba.buildpacksDir)
+	buildpackStore, cleanup, err := initBuildpackStore(ba.buildpacksDir, ba.buildpackages)
 	if err != nil {
 		return cmd.FailErrCode(err, ba.platform.CodeFor(cmd.BuildError), "build")
 	}
+	defer cleanup()
 
+	stdout, stderr := cmd.BuildpackOutput()
 	builder := &lifecycle.Builder{
 		AppDir:         ba.appDir,
 		LayersDir:      ba.layersDir,
 		PlatformDir:    ba.platformDir,
 		Platform:       ba.platform,
 		PlatformAPI:    api.MustParse(ba.platform.API()),
+		BuildTimeout:   ba.timeout,
+		Offline:        ba.offline,
+		Strict:         ba.strict,
+		LogPrefix:      ba.logPrefixType,
 		Group:          group,
 		Plan:           plan,
-		Out:            cmd.Stdout,
-		Err:            cmd.Stderr,
-		Logger:         cmd.DefaultLogger,
+		Out:            stdout,
+		Err:            stderr,
+		Logger:         attributedLogger{cmd.DefaultLogger},
 		BuildpackStore: buildpackStore,
 	}
+	for _, path := range strings.Split(ba.protectedAppPaths, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			builder.ProtectedAppPaths = append(builder.ProtectedAppPaths, path)
+		}
+	}
+	builder.UsageReport = ba.readUsageReport()
+	defer ba.writeUsageReport(builder.UsageReport)
+	if ba.resume {
+		builder.CheckpointPath = filepath.Join(ba.layersDir, cmd.DefaultBuildCheckpointFile)
+	}
 	md, err := builder.Build()
 
 	if err != nil {
 		if err, ok := err.(*buildpack.Error); ok {
-			if err.Type == buildpack.ErrTypeBuildpack {
+			switch err.Type {
+			case buildpack.ErrTypeBuildpack:
 				return cmd.FailErrCode(err.Cause(), ba.platform.CodeFor(cmd.FailedBuildWithErrors), "build")
+			case buildpack.ErrTypeTimeout:
+				return cmd.FailErrCode(err.Cause(), ba.platform.CodeFor(cmd.FailedBuildWithTimeout), "build")
 			}
 		}
 		return cmd.FailErrCode(err, ba.platform.CodeFor(cmd.BuildError), "build")
@@ -123,3 +166,21 @@ func (b *buildCmd) readData() (buildpack.Group, platform.BuildPlan, error) {
 	}
 	return group, plan, nil
 }
+
+// readUsageReport reads the build report started by the detector, so that the resources used by each /bin/build
+// are reported alongside those used by each /bin/detect.
+func (ba buildArgs) readUsageReport() *platform.UsageReport {
+	report := &platform.UsageReport{}
+	if _, err := toml.DecodeFile(filepath.Join(ba.layersDir, cmd.DefaultBuildReportFile), report); err != nil && !os.IsNotExist(err) {
+		cmd.DefaultLogger.Warnf("Failed to read build report: %s", err)
+	}
+	report.Build = nil
+	report.AppChanges = nil
+	return report
+}
+
+func (ba buildArgs) writeUsageReport(report *platform.UsageReport) {
+	if err := lifecycle.WriteTOML(filepath.Join(ba.layersDir, cmd.DefaultBuildReportFile), report); err != nil {
+		cmd.DefaultLogger.Warnf("Failed to write build report: %s", err)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/docker/docker/client"
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -19,27 +20,47 @@ import (
 type createCmd struct {
 	//flags: inputs
 	appDir              string
+	buildpackages       string
 	buildpacksDir       string
 	cacheDir            string
 	cacheImageRef       string
+	createdTime         string
+	groupPinPath        string
 	launchCacheDir      string
 	launcherPath        string
 	layersDir           string
+	logPrefix           string
+	logPrefixType       buildpack.LogPrefix
+	offline             bool
 	orderPath           string
 	outputImageRef      string
 	platformDir         string
 	previousImageRef    string
 	processType         string
 	projectMetadataPath string
+	protectedAppPaths   string
 	registry            string
 	reportPath          string
+	resume              bool
 	runImageRef         string
+	sbom                bool
 	stackPath           string
+	strict              bool
 	uid, gid            int
+	buildTimeout        time.Duration
+	detectCache         bool
+	detectParallelism   int
+	detectTimeout       time.Duration
+	exportParallelism   int
+	created             time.Time
+	layerCreatedTime    bool
+	ociLabels           map[string]string
+	planVersions        bool
 	skipRestore         bool
 	useDaemon           bool
 
 	additionalTags cmd.StringSlice
+	ociLabelFlags  cmd.StringSlice
 	docker         client.CommonAPIClient // construct if necessary before dropping privileges
 	keychain       authn.Keychain
 	platform       cmd.Platform
@@ -48,24 +69,41 @@ type createCmd struct {
 
 func (c *createCmd) DefineFlags() {
 	cmd.FlagAppDir(&c.appDir)
+	cmd.FlagBuildpackages(&c.buildpackages)
 	cmd.FlagBuildpacksDir(&c.buildpacksDir)
+	cmd.FlagBuildTimeout(&c.buildTimeout)
 	cmd.FlagCacheDir(&c.cacheDir)
 	cmd.FlagCacheImage(&c.cacheImageRef)
+	cmd.FlagCreatedTime(&c.createdTime)
+	cmd.FlagDetectCache(&c.detectCache)
+	cmd.FlagDetectParallelism(&c.detectParallelism)
+	cmd.FlagExportParallelism(&c.exportParallelism)
+	cmd.FlagDetectTimeout(&c.detectTimeout)
 	cmd.FlagGID(&c.gid)
+	cmd.FlagGroupPinPath(&c.groupPinPath)
 	cmd.FlagLaunchCacheDir(&c.launchCacheDir)
 	cmd.FlagLauncherPath(&c.launcherPath)
+	cmd.FlagLayerCreatedTime(&c.layerCreatedTime)
 	cmd.FlagLayersDir(&c.layersDir)
+	cmd.FlagLogPrefix(&c.logPrefix)
+	cmd.FlagOCILabels(&c.ociLabelFlags)
+	cmd.FlagOffline(&c.offline)
 	cmd.FlagOrderPath(&c.orderPath)
+	cmd.FlagPlanVersions(&c.planVersions)
 	cmd.FlagPlatformDir(&c.platformDir)
 	cmd.FlagPreviousImage(&c.previousImageRef)
 	cmd.FlagReportPath(&c.reportPath)
+	cmd.FlagResume(&c.resume)
 	cmd.FlagRunImage(&c.runImageRef)
+	cmd.FlagSBOM(&c.sbom)
 	cmd.FlagSkipRestore(&c.skipRestore)
 	cmd.FlagStackPath(&c.stackPath)
+	cmd.FlagStrict(&c.strict)
 	cmd.FlagUID(&c.uid)
 	cmd.FlagUseDaemon(&c.useDaemon)
 	cmd.FlagTags(&c.additionalTags)
 	cmd.FlagProjectMetadataPath(&c.projectMetadataPath)
+	cmd.FlagProtectedAppPaths(&c.protectedAppPaths)
 	cmd.FlagProcessType(&c.processType)
 }
 
@@ -105,6 +143,18 @@ func (c *createCmd) Args(nargs int, args []string) error {
 	}
 
 	var err error
+	if c.logPrefixType, err = buildpack.ParseLogPrefix(c.logPrefix); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse log prefix")
+	}
+
+	if c.created, err = parseCreatedTime(c.createdTime, c.layerCreatedTime); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse created time")
+	}
+
+	if c.ociLabels, err = parseOCILabels(c.ociLabelFlags); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse oci labels")
+	}
+
 	c.stackMD, c.runImageRef, c.registry, err = resolveStack(c.outputImageRef, c.stackPath, c.runImageRef)
 	if err != nil {
 		return err
@@ -177,26 +227,44 @@ func (c *createCmd) Exec() error {
 		}
 
 		cmd.DefaultLogger.Phase("DETECTING")
-		group, plan, err = detectArgs{
+		group, plan, _, err = detectArgs{
 			buildpacksDir: c.buildpacksDir,
+			buildpackages: c.buildpackages,
 			appDir:        c.appDir,
 			layersDir:     c.layersDir,
 			platform:      c.platform,
 			platformDir:   c.platformDir,
 			orderPath:     c.orderPath,
+			groupPinPath:  c.groupPinPath,
+			parallelism:   c.detectParallelism,
+			detectCache:   c.detectCache,
+			offline:       c.offline,
+			logPrefixType: c.logPrefixType,
+			cache:         cacheStore,
+			planVersions:  c.planVersions,
+			timeout:       c.detectTimeout,
 		}.detect()
 		if err != nil {
 			return err
 		}
 	} else {
 		cmd.DefaultLogger.Phase("DETECTING")
-		group, plan, err = detectArgs{
+		group, plan, _, err = detectArgs{
 			buildpacksDir: c.buildpacksDir,
+			buildpackages: c.buildpackages,
 			appDir:        c.appDir,
 			layersDir:     c.layersDir,
 			platform:      c.platform,
 			platformDir:   c.platformDir,
 			orderPath:     c.orderPath,
+			groupPinPath:  c.groupPinPath,
+			parallelism:   c.detectParallelism,
+			detectCache:   c.detectCache,
+			offline:       c.offline,
+			logPrefixType: c.logPrefixType,
+			cache:         cacheStore,
+			planVersions:  c.planVersions,
+			timeout:       c.detectTimeout,
 		}.detect()
 		if err != nil {
 			return err
@@ -236,11 +304,18 @@ func (c *createCmd) Exec() error {
 
 	cmd.DefaultLogger.Phase("BUILDING")
 	err = buildArgs{
-		buildpacksDir: c.buildpacksDir,
-		layersDir:     c.layersDir,
-		appDir:        c.appDir,
-		platform:      c.platform,
-		platformDir:   c.platformDir,
+		buildpacksDir:     c.buildpacksDir,
+		buildpackages:     c.buildpackages,
+		layersDir:         c.layersDir,
+		appDir:            c.appDir,
+		logPrefixType:     c.logPrefixType,
+		offline:           c.offline,
+		platform:          c.platform,
+		platformDir:       c.platformDir,
+		protectedAppPaths: c.protectedAppPaths,
+		resume:            c.resume,
+		strict:            c.strict,
+		timeout:           c.buildTimeout,
 	}.build(group, plan)
 	if err != nil {
 		return err
@@ -249,19 +324,24 @@ func (c *createCmd) Exec() error {
 	cmd.DefaultLogger.Phase("EXPORTING")
 	return exportArgs{
 		appDir:              c.appDir,
+		created:             c.created,
 		docker:              c.docker,
 		gid:                 c.gid,
 		imageNames:          append([]string{c.outputImageRef}, c.additionalTags...),
 		keychain:            c.keychain,
 		launchCacheDir:      c.launchCacheDir,
+		parallelism:         c.exportParallelism,
 		launcherPath:        c.launcherPath,
+		layerCreatedTime:    c.layerCreatedTime,
 		layersDir:           c.layersDir,
+		ociLabels:           c.ociLabels,
 		platform:            c.platform,
 		processType:         c.processType,
 		projectMetadataPath: c.projectMetadataPath,
 		registry:            c.registry,
 		reportPath:          c.reportPath,
 		runImageRef:         c.runImageRef,
+		sbom:                c.sbom,
 		stackMD:             c.stackMD,
 		stackPath:           c.stackPath,
 		uid:                 c.uid,
@@ -3,8 +3,11 @@ package main
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
+	"time"
 
 	"github.com/buildpacks/lifecycle"
+	"github.com/buildpacks/lifecycle/auth"
 	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/cmd"
 	"github.com/buildpacks/lifecycle/platform"
@@ -13,32 +16,56 @@ import (
 
 type detectCmd struct {
 	// flags: inputs
+	cacheDir      string
+	cacheImageTag string
+	logPrefix     string
 	detectArgs
 
 	// flags: paths to write outputs
-	groupPath string
-	planPath  string
+	groupPath  string
+	planPath   string
+	reportPath string
 }
 
 type detectArgs struct {
 	// inputs needed when run by creator
 	buildpacksDir string
+	buildpackages string
 	appDir        string
 	layersDir     string
 	platformDir   string
 	orderPath     string
+	groupPinPath  string
+	parallelism   int
+	planVersions  bool
+	timeout       time.Duration
+	detectCache   bool
+	offline       bool
+	logPrefixType buildpack.LogPrefix
 
+	cache    lifecycle.Cache
 	platform cmd.Platform
 }
 
 func (d *detectCmd) DefineFlags() {
 	cmd.FlagBuildpacksDir(&d.buildpacksDir)
+	cmd.FlagBuildpackages(&d.buildpackages)
 	cmd.FlagAppDir(&d.appDir)
+	cmd.FlagCacheDir(&d.cacheDir)
+	cmd.FlagCacheImage(&d.cacheImageTag)
+	cmd.FlagDetectCache(&d.detectCache)
 	cmd.FlagLayersDir(&d.layersDir)
+	cmd.FlagLogPrefix(&d.logPrefix)
+	cmd.FlagOffline(&d.offline)
 	cmd.FlagPlatformDir(&d.platformDir)
 	cmd.FlagOrderPath(&d.orderPath)
 	cmd.FlagGroupPath(&d.groupPath)
+	cmd.FlagGroupPinPath(&d.groupPinPath)
 	cmd.FlagPlanPath(&d.planPath)
+	cmd.FlagPlanVersions(&d.planVersions)
+	cmd.FlagDetectParallelism(&d.parallelism)
+	cmd.FlagDetectReportPath(&d.reportPath)
+	cmd.FlagDetectTimeout(&d.timeout)
 }
 
 func (d *detectCmd) Args(nargs int, args []string) error {
@@ -54,10 +81,19 @@ func (d *detectCmd) Args(nargs int, args []string) error {
 		d.planPath = cmd.DefaultPlanPath(d.platform.API(), d.layersDir)
 	}
 
+	if d.reportPath == cmd.PlaceholderDetectReportPath {
+		d.reportPath = cmd.DefaultDetectReportPath(d.platform.API(), d.layersDir)
+	}
+
 	if d.orderPath == cmd.PlaceholderOrderPath {
 		d.orderPath = cmd.DefaultOrderPath(d.platform.API(), d.layersDir)
 	}
 
+	var err error
+	if d.logPrefixType, err = buildpack.ParseLogPrefix(d.logPrefix); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse log prefix")
+	}
+
 	return nil
 }
 
@@ -70,62 +106,119 @@ func (d *detectCmd) Privileges() error {
 }
 
 func (d *detectCmd) Exec() error {
-	group, plan, err := d.detect()
+	if d.detectCache {
+		keychain, err := auth.DefaultKeychain(d.cacheImageTag)
+		if err != nil {
+			return cmd.FailErr(err, "resolve keychain")
+		}
+		if d.cache, err = initCache(d.cacheImageTag, d.cacheDir, keychain); err != nil {
+			return err
+		}
+	}
+	group, plan, report, err := d.detect()
+	if report != nil {
+		// the report is most useful when detection fails, so write it before handling the error
+		if err := lifecycle.WriteJSON(d.reportPath, report); err != nil {
+			cmd.DefaultLogger.Warnf("Failed to write detect report: %s", err)
+		}
+	}
 	if err != nil {
 		return err
 	}
 	return d.writeData(group, plan)
 }
 
-func (da detectArgs) detect() (buildpack.Group, platform.BuildPlan, error) {
-	order, err := lifecycle.ReadOrder(da.orderPath)
+func (da detectArgs) detect() (buildpack.Group, platform.BuildPlan, *platform.DetectReport, error) {
+	var (
+		order  buildpack.Order
+		pinned buildpack.Group
+		err    error
+	)
+	if da.groupPinPath != "" {
+		pinned, err = lifecycle.ReadGroup(da.groupPinPath)
+		if err != nil {
+			return buildpack.Group{}, platform.BuildPlan{}, nil, cmd.FailErr(err, "read pinned buildpack group")
+		}
+		order = buildpack.Order{pinned}
+	} else {
+		order, err = lifecycle.ReadOrder(da.orderPath)
+		if err != nil {
+			return buildpack.Group{}, platform.BuildPlan{}, nil, cmd.FailErr(err, "read buildpack order file")
+		}
+	}
+	store, cleanup, err := initBuildpackStore(da.buildpacksDir, da.buildpackages)
 	if err != nil {
-		return buildpack.Group{}, platform.BuildPlan{}, cmd.FailErr(err, "read buildpack order file")
+		return buildpack.Group{}, platform.BuildPlan{}, nil, cmd.FailErr(err, "initialize detector")
 	}
-	if err := da.verifyBuildpackApis(order); err != nil {
-		return buildpack.Group{}, platform.BuildPlan{}, err
+	defer cleanup()
+	if err := da.verifyBuildpackApis(store, order); err != nil {
+		return buildpack.Group{}, platform.BuildPlan{}, nil, err
 	}
 
-	detector, err := lifecycle.NewDetector(
+	detector := lifecycle.NewDetector(
 		buildpack.DetectConfig{
 			AppDir:      da.appDir,
 			PlatformDir: da.platformDir,
-			Logger:      cmd.DefaultLogger,
+			Timeout:     da.timeout,
+			Offline:     da.offline,
+			LogPrefix:   da.logPrefixType,
+			Logger:      attributedLogger{cmd.DefaultLogger},
 		},
-		da.buildpacksDir,
+		store,
 		da.platform,
 	)
-	if err != nil {
-		return buildpack.Group{}, platform.BuildPlan{}, cmd.FailErr(err, "initialize detector")
+	detector.Parallelism = da.parallelism
+	if da.parallelism > 0 {
+		cmd.DefaultLogger.Debugf("Running detection with parallelism %d", da.parallelism)
+	}
+	detector.UsageReport = &platform.UsageReport{}
+	defer da.writeUsageReport(detector.UsageReport)
+	if da.detectCache {
+		detector.Cache = da.initDetectCache()
+		defer da.writeDetectCache(detector.Cache)
+	}
+	if resolver, ok := detector.Resolver.(*lifecycle.DefaultResolver); ok {
+		resolver.VersionConstraints = da.planVersions
+	}
+	var (
+		group buildpack.Group
+		plan  platform.BuildPlan
+	)
+	failMsg := "No buildpack groups passed detection."
+	if da.groupPinPath != "" {
+		failMsg = "Pinned buildpack group failed detection."
+		group, plan, err = detector.DetectPinned(pinned)
+	} else {
+		group, plan, err = detector.Detect(order)
 	}
-	group, plan, err := detector.Detect(order)
 	if err != nil {
 		switch err := err.(type) {
 		case *buildpack.Error:
 			switch err.Type {
 			case buildpack.ErrTypeFailedDetection:
-				cmd.DefaultLogger.Error("No buildpack groups passed detection.")
-				cmd.DefaultLogger.Error("Please check that you are running against the correct path.")
-				return buildpack.Group{}, platform.BuildPlan{}, cmd.FailErrCode(err, da.platform.CodeFor(cmd.FailedDetect), "detect")
+				cmd.DefaultLogger.Error(failMsg)
+				if da.groupPinPath == "" {
+					cmd.DefaultLogger.Error("Please check that you are running against the correct path.")
+				}
+				return buildpack.Group{}, platform.BuildPlan{}, detector.Report, cmd.FailErrCode(err, da.platform.CodeFor(cmd.FailedDetect), "detect")
 			case buildpack.ErrTypeBuildpack:
-				cmd.DefaultLogger.Error("No buildpack groups passed detection.")
-				return buildpack.Group{}, platform.BuildPlan{}, cmd.FailErrCode(err, da.platform.CodeFor(cmd.FailedDetectWithErrors), "detect")
+				cmd.DefaultLogger.Error(failMsg)
+				return buildpack.Group{}, platform.BuildPlan{}, detector.Report, cmd.FailErrCode(err, da.platform.CodeFor(cmd.FailedDetectWithErrors), "detect")
+			case buildpack.ErrTypeTimeout:
+				cmd.DefaultLogger.Error(failMsg)
+				return buildpack.Group{}, platform.BuildPlan{}, detector.Report, cmd.FailErrCode(err, da.platform.CodeFor(cmd.FailedDetectWithTimeout), "detect")
 			default:
-				return buildpack.Group{}, platform.BuildPlan{}, cmd.FailErrCode(err, da.platform.CodeFor(cmd.DetectError), "detect")
+				return buildpack.Group{}, platform.BuildPlan{}, detector.Report, cmd.FailErrCode(err, da.platform.CodeFor(cmd.DetectError), "detect")
 			}
 		default:
-			return buildpack.Group{}, platform.BuildPlan{}, cmd.FailErrCode(err, da.platform.CodeFor(cmd.DetectError), "detect")
+			return buildpack.Group{}, platform.BuildPlan{}, detector.Report, cmd.FailErrCode(err, da.platform.CodeFor(cmd.DetectError), "detect")
 		}
 	}
 
-	return group, plan, nil
+	return group, plan, detector.Report, nil
 }
 
-func (da detectArgs) verifyBuildpackApis(order buildpack.Order) error {
-	store, err := buildpack.NewBuildpackStore(da.buildpacksDir)
-	if err != nil {
-		return err
-	}
+func (da detectArgs) verifyBuildpackApis(store lifecycle.BuildpackStore, order buildpack.Order) error {
 	for _, group := range order {
 		for _, groupBp := range group.Group {
 			buildpack, err := store.Lookup(groupBp.ID, groupBp.Version)
@@ -150,3 +243,36 @@ func (d *detectCmd) writeData(group buildpack.Group, plan platform.BuildPlan) er
 	}
 	return nil
 }
+
+func (da detectArgs) initDetectCache() *lifecycle.DetectCache {
+	if da.cache == nil {
+		cmd.DefaultLogger.Warn("Detect cache requires a cache directory or cache image, detect results will not be reused")
+		return nil
+	}
+	cacheMD, err := da.cache.RetrieveMetadata()
+	if err != nil {
+		cmd.DefaultLogger.Warnf("Failed to read detect cache: %s", err)
+	}
+	return &lifecycle.DetectCache{
+		AppDir:      da.appDir,
+		PlatformDir: da.platformDir,
+		Previous:    cacheMD.Detect,
+	}
+}
+
+// writeDetectCache saves the detect results of this build to be stored in the cache by the exporter.
+func (da detectArgs) writeDetectCache(detectCache *lifecycle.DetectCache) {
+	if detectCache == nil {
+		return
+	}
+	if err := lifecycle.WriteJSON(filepath.Join(da.layersDir, cmd.DefaultDetectCacheFile), detectCache.Entries()); err != nil {
+		cmd.DefaultLogger.Warnf("Failed to write detect cache: %s", err)
+	}
+}
+
+// writeUsageReport starts the build report of this build with the resources used by each /bin/detect.
+func (da detectArgs) writeUsageReport(report *platform.UsageReport) {
+	if err := lifecycle.WriteTOML(filepath.Join(da.layersDir, cmd.DefaultBuildReportFile), report); err != nil {
+		cmd.DefaultLogger.Warnf("Failed to write build report: %s", err)
+	}
+}
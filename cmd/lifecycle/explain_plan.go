@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/buildpacks/lifecycle"
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+type explainPlanCmd struct {
+	// flags: inputs
+	buildpacksDir string
+	layersDir     string
+	orderPath     string
+	outputsPath   string
+	planVersions  bool
+
+	platform cmd.Platform
+}
+
+func (e *explainPlanCmd) DefineFlags() {
+	cmd.FlagBuildpacksDir(&e.buildpacksDir)
+	cmd.FlagDetectReportPath(&e.outputsPath)
+	cmd.FlagLayersDir(&e.layersDir)
+	cmd.FlagOrderPath(&e.orderPath)
+	cmd.FlagPlanVersions(&e.planVersions)
+}
+
+func (e *explainPlanCmd) Args(nargs int, args []string) error {
+	if nargs != 0 {
+		return cmd.FailErrCode(errors.New("received unexpected arguments"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+
+	if e.outputsPath == cmd.PlaceholderDetectReportPath {
+		e.outputsPath = cmd.DefaultDetectReportPath(e.platform.API(), e.layersDir)
+	}
+
+	if e.orderPath == cmd.PlaceholderOrderPath {
+		e.orderPath = cmd.DefaultOrderPath(e.platform.API(), e.layersDir)
+	}
+
+	return nil
+}
+
+func (e *explainPlanCmd) Privileges() error {
+	return nil
+}
+
+func (e *explainPlanCmd) Exec() error {
+	order, err := lifecycle.ReadOrder(e.orderPath)
+	if err != nil {
+		return cmd.FailErr(err, "read buildpack order file")
+	}
+	outputs, err := readDetectOutputs(e.outputsPath)
+	if err != nil {
+		return cmd.FailErr(err, "read detect outputs")
+	}
+
+	explainer := &lifecycle.PlanExplainer{
+		Logger:             attributedLogger{cmd.DefaultLogger},
+		Platform:           e.platform,
+		VersionConstraints: e.planVersions,
+	}
+	if store, err := buildpack.NewBuildpackStore(e.buildpacksDir); err == nil {
+		explainer.Store = store
+	}
+
+	group, plan, report, err := explainer.Explain(order, lifecycle.RecordedDetectRuns(outputs))
+	printExplanation(cmd.Stdout, report, group, plan)
+	if err != nil {
+		return cmd.FailErrCode(err, e.platform.CodeFor(cmd.FailedDetect), "explain plan")
+	}
+	return nil
+}
+
+// readDetectOutputs reads the buildpack detect outputs recorded in a detect report (.json),
+// or listed as [[buildpacks]] in a TOML file.
+func readDetectOutputs(path string) ([]platform.DetectBuildpackReport, error) {
+	var outputs []platform.DetectBuildpackReport
+	if filepath.Ext(path) == ".toml" {
+		var recorded struct {
+			Buildpacks []platform.DetectBuildpackReport `toml:"buildpacks"`
+		}
+		if _, err := toml.DecodeFile(path, &recorded); err != nil {
+			return nil, err
+		}
+		return recorded.Buildpacks, nil
+	}
+	var report platform.DetectReport
+	if err := lifecycle.ReadJSON(path, &report); err != nil {
+		return nil, err
+	}
+	for _, group := range report.Groups {
+		outputs = append(outputs, group.Buildpacks...)
+	}
+	return outputs, nil
+}
+
+func printExplanation(w io.Writer, report *platform.DetectReport, group buildpack.Group, plan platform.BuildPlan) {
+	for i, groupReport := range report.Groups {
+		var bps []string
+		for _, bp := range groupReport.Buildpacks {
+			bps = append(bps, bpName(bp.GroupBuildpack))
+		}
+		fmt.Fprintf(w, "Group #%d: %s\n", i+1, strings.Join(bps, ", "))
+		for _, bp := range groupReport.Buildpacks {
+			fmt.Fprintf(w, "  %s: %s\n", bp.GroupBuildpack, bp.Result)
+		}
+		for j, trial := range groupReport.Trials {
+			var trialBps []string
+			for _, bp := range trial.Buildpacks {
+				trialBps = append(trialBps, bpName(bp))
+			}
+			fmt.Fprintf(w, "  Trial #%d: %s\n", j+1, strings.Join(trialBps, ", "))
+			for _, reason := range trial.Skipped {
+				fmt.Fprintf(w, "    dropped: %s\n", reason)
+			}
+			if trial.Pass {
+				fmt.Fprintln(w, "    pass")
+			} else {
+				fmt.Fprintf(w, "    fail: %s\n", trial.Error)
+			}
+		}
+		if groupReport.Pass {
+			fmt.Fprintln(w, "  Result: pass")
+		} else {
+			fmt.Fprintf(w, "  Result: fail: %s\n", groupReport.Error)
+		}
+	}
+
+	if len(group.Group) == 0 {
+		fmt.Fprintln(w, "No group passed detection")
+		return
+	}
+	fmt.Fprintln(w, "Selected group:")
+	for _, bp := range group.Group {
+		fmt.Fprintf(w, "  %s\n", bp)
+	}
+	fmt.Fprintln(w, "Build plan:")
+	for _, entry := range plan.Entries {
+		if len(entry.Requires) == 0 {
+			continue
+		}
+		var providers []string
+		for _, bp := range entry.Providers {
+			providers = append(providers, bp.String())
+		}
+		name := entry.Requires[0].Name // requires in an entry share the name of the provide
+		fmt.Fprintf(w, "  %s: provided by %s\n", name, strings.Join(providers, ", "))
+	}
+}
+
+func bpName(bp buildpack.GroupBuildpack) string {
+	if bp.Optional {
+		return bp.String() + " (optional)"
+	}
+	return bp.String()
+}
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/buildpacks/imgutil"
@@ -12,6 +17,8 @@ import (
 	"github.com/docker/docker/client"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	ggcrremote "github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pkg/errors"
 
 	"github.com/buildpacks/lifecycle"
@@ -32,8 +39,11 @@ type exportCmd struct {
 	//flags: inputs
 	cacheDir              string
 	cacheImageTag         string
+	createdTime           string
 	groupPath             string
 	deprecatedRunImageRef string
+	ociLabelFlags         cmd.StringSlice
+	outputFile            string
 	exportArgs
 
 	//flags: paths to write outputs
@@ -43,15 +53,21 @@ type exportCmd struct {
 type exportArgs struct {
 	// inputs needed when run by creator
 	appDir              string
+	created             time.Time // when set, the image created time
 	imageNames          []string
 	launchCacheDir      string
 	launcherPath        string
+	layerCreatedTime    bool // when set, files in app and buildpack layers are given the created time
 	layersDir           string
+	ociLabels           map[string]string // org.opencontainers.image labels provided by the platform
+	output              image.Output      // when set, the image is written to a file instead of a registry or daemon
+	parallelism         int
 	processType         string
 	projectMetadataPath string
 	registry            string
 	reportPath          string
 	runImageRef         string
+	sbom                bool
 	stackMD             platform.StackMetadata
 	stackPath           string
 	useDaemon           bool
@@ -69,15 +85,21 @@ func (e *exportCmd) DefineFlags() {
 	cmd.FlagAppDir(&e.appDir)
 	cmd.FlagCacheDir(&e.cacheDir)
 	cmd.FlagCacheImage(&e.cacheImageTag)
+	cmd.FlagCreatedTime(&e.createdTime)
 	cmd.FlagGID(&e.gid)
 	cmd.FlagGroupPath(&e.groupPath)
 	cmd.FlagLaunchCacheDir(&e.launchCacheDir)
 	cmd.FlagLauncherPath(&e.launcherPath)
+	cmd.FlagLayerCreatedTime(&e.layerCreatedTime)
 	cmd.FlagLayersDir(&e.layersDir)
+	cmd.FlagOCILabels(&e.ociLabelFlags)
+	cmd.FlagOutput(&e.outputFile)
+	cmd.FlagExportParallelism(&e.parallelism)
 	cmd.FlagProcessType(&e.processType)
 	cmd.FlagProjectMetadataPath(&e.projectMetadataPath)
 	cmd.FlagReportPath(&e.reportPath)
 	cmd.FlagRunImage(&e.runImageRef)
+	cmd.FlagSBOM(&e.sbom)
 	cmd.FlagStackPath(&e.stackPath)
 	cmd.FlagUID(&e.uid)
 	cmd.FlagUseDaemon(&e.useDaemon)
@@ -100,7 +122,29 @@ func (e *exportCmd) Args(nargs int, args []string) error {
 		cmd.DefaultLogger.Warn("Will not cache data, no cache flag specified.")
 	}
 
-	if err := image.ValidateDestinationTags(e.useDaemon, e.imageNames...); err != nil {
+	if e.outputFile != "" {
+		if e.useDaemon {
+			return cmd.FailErrCode(errors.New("supply only one of -daemon or -output"), cmd.CodeInvalidArgs, "parse arguments")
+		}
+		output, err := image.ParseOutput(e.outputFile)
+		if err != nil {
+			return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse output")
+		}
+		e.output = output
+	}
+
+	var err error
+	e.created, err = parseCreatedTime(e.createdTime, e.layerCreatedTime)
+	if err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse created time")
+	}
+
+	if e.ociLabels, err = parseOCILabels(e.ociLabelFlags); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse oci labels")
+	}
+
+	// images written to a file, like images written to a daemon, are not pushed to a registry
+	if err := image.ValidateDestinationTags(e.useDaemon || e.output.Path != "", e.imageNames...); err != nil {
 		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "validate image tag(s)")
 	}
 
@@ -128,7 +172,6 @@ func (e *exportCmd) Args(nargs int, args []string) error {
 		e.runImageRef = e.deprecatedRunImageRef
 	}
 
-	var err error
 	e.stackMD, e.runImageRef, e.registry, err = resolveStack(e.imageNames[0], e.stackPath, e.runImageRef)
 	if err != nil {
 		return err
@@ -195,7 +238,9 @@ func (e *exportCmd) registryImages() []string {
 	if e.cacheImageTag != "" {
 		registryImages = append(registryImages, e.cacheImageTag)
 	}
-	if !e.useDaemon {
+	if e.output.Path != "" {
+		registryImages = append(registryImages, e.runImageRef)
+	} else if !e.useDaemon {
 		registryImages = append(registryImages, e.imageNames...)
 		registryImages = append(registryImages, e.runImageRef)
 		if e.analyzedMD.Image != nil {
@@ -221,23 +266,43 @@ func (ea exportArgs) export(group buildpack.Group, cacheStore lifecycle.Cache, a
 		cmd.DefaultLogger.Debugf("no project metadata found at path '%s', project metadata will not be exported\n", ea.projectMetadataPath)
 	}
 
+	var detectCache []platform.DetectCacheEntry
+	if err := lifecycle.ReadJSON(filepath.Join(ea.layersDir, cmd.DefaultDetectCacheFile), &detectCache); err != nil && !os.IsNotExist(err) {
+		cmd.DefaultLogger.Warnf("Failed to read detect cache: %s", err)
+	}
+
 	exporter := &lifecycle.Exporter{
-		Buildpacks: group.Group,
+		Buildpacks:  group.Group,
+		DetectCache: detectCache,
 		LayerFactory: &layers.Factory{
 			ArtifactsDir: artifactsDir,
 			UID:          ea.uid,
 			GID:          ea.gid,
 			Logger:       cmd.DefaultLogger,
+			Parallelism:  ea.parallelism,
+			ModTime:      ea.layerModTime(),
 		},
-		Logger:      cmd.DefaultLogger,
+		Logger:      attributedLogger{cmd.DefaultLogger},
 		PlatformAPI: api.MustParse(ea.platform.API()),
+		SBOM:        ea.sbom,
 	}
 
 	var appImage imgutil.Image
 	var runImageID string
-	if ea.useDaemon {
+	origMetadata := analyzedMD.Metadata
+	// imgutil always creates images in a daemon or a registry at imgutil.NormalizedDateTime,
+	// so images with a created time are built with go-containerregistry instead
+	switch {
+	case ea.useDaemon && !ea.created.IsZero():
+		appImage, runImageID, err = ea.initCreatedDaemonAppImage(analyzedMD, artifactsDir)
+	case ea.useDaemon:
 		appImage, runImageID, err = ea.initDaemonAppImage(analyzedMD)
-	} else {
+	case ea.output.Path != "":
+		// the previous image analyzed in a registry is ignored, layers are only reused from the same output
+		appImage, runImageID, origMetadata, err = ea.initFileAppImage()
+	case !ea.created.IsZero():
+		appImage, runImageID, err = ea.initCreatedRemoteAppImage(analyzedMD)
+	default:
 		appImage, runImageID, err = ea.initRemoteAppImage(analyzedMD)
 	}
 	if err != nil {
@@ -247,10 +312,12 @@ func (ea exportArgs) export(group buildpack.Group, cacheStore lifecycle.Cache, a
 	report, err := exporter.Export(lifecycle.ExportOptions{
 		AdditionalNames:    ea.imageNames[1:],
 		AppDir:             ea.appDir,
+		CreatedTime:        ea.created,
 		DefaultProcessType: ea.processType,
 		LauncherConfig:     launcherConfig(ea.launcherPath),
 		LayersDir:          ea.layersDir,
-		OrigMetadata:       analyzedMD.Metadata,
+		OCILabels:          ea.ociLabels,
+		OrigMetadata:       origMetadata,
 		Project:            projectMD,
 		RunImageRef:        runImageID,
 		Stack:              ea.stackMD,
@@ -296,16 +363,59 @@ func (ea exportArgs) initDaemonAppImage(analyzedMD platform.AnalyzedMetadata) (i
 		return nil, "", cmd.FailErr(err, "get run image ID")
 	}
 
-	if ea.launchCacheDir != "" {
-		volumeCache, err := cache.NewVolumeCache(ea.launchCacheDir)
-		if err != nil {
-			return nil, "", cmd.FailErr(err, "create launch cache")
+	appImage, err = ea.withLaunchCache(appImage)
+	if err != nil {
+		return nil, "", err
+	}
+	return appImage, runImageID.String(), nil
+}
+
+func (ea exportArgs) initCreatedDaemonAppImage(analyzedMD platform.AnalyzedMetadata, tmpDir string) (imgutil.Image, string, error) {
+	runImage, err := image.ReadDaemonImage(ea.docker, ea.runImageRef, tmpDir)
+	if err != nil {
+		return nil, "", cmd.FailErr(err, "access run image")
+	}
+	if runImage == nil {
+		return nil, "", cmd.FailErr(fmt.Errorf("image '%s' does not exist in the daemon", ea.runImageRef), "access run image")
+	}
+
+	var prevImage v1.Image
+	if analyzedMD.Image != nil {
+		cmd.DefaultLogger.Debugf("Reusing layers from image with id '%s'", analyzedMD.Image.Reference)
+		if prevImage, err = image.ReadDaemonImage(ea.docker, analyzedMD.Image.Reference, tmpDir); err != nil {
+			return nil, "", cmd.FailErr(err, "access previous image")
 		}
-		appImage = cache.NewCachingImage(appImage, volumeCache)
+	}
+
+	var appImage imgutil.Image
+	appImage, err = image.NewDaemonImage(ea.imageNames[0], ea.docker, runImage, prevImage)
+	if err != nil {
+		return nil, "", cmd.FailErr(err, "create new app image")
+	}
+
+	runImageID, err := appImage.Identifier()
+	if err != nil {
+		return nil, "", cmd.FailErr(err, "get run image ID")
+	}
+
+	appImage, err = ea.withLaunchCache(appImage)
+	if err != nil {
+		return nil, "", err
 	}
 	return appImage, runImageID.String(), nil
 }
 
+func (ea exportArgs) withLaunchCache(appImage imgutil.Image) (imgutil.Image, error) {
+	if ea.launchCacheDir == "" {
+		return appImage, nil
+	}
+	volumeCache, err := cache.NewVolumeCache(ea.launchCacheDir)
+	if err != nil {
+		return nil, cmd.FailErr(err, "create launch cache")
+	}
+	return cache.NewCachingImage(appImage, volumeCache), nil
+}
+
 func (ea exportArgs) initRemoteAppImage(analyzedMD platform.AnalyzedMetadata) (imgutil.Image, string, error) {
 	var opts = []remote.ImageOption{
 		remote.FromBaseImage(ea.runImageRef),
@@ -313,13 +423,8 @@ func (ea exportArgs) initRemoteAppImage(analyzedMD platform.AnalyzedMetadata) (i
 
 	if analyzedMD.Image != nil {
 		cmd.DefaultLogger.Infof("Reusing layers from image '%s'", analyzedMD.Image.Reference)
-		ref, err := name.ParseReference(analyzedMD.Image.Reference, name.WeakValidation)
-		if err != nil {
-			return nil, "", cmd.FailErr(err, "parse analyzed registry")
-		}
-		analyzedRegistry := ref.Context().RegistryStr()
-		if analyzedRegistry != ea.registry {
-			return nil, "", fmt.Errorf("analyzed image is on a different registry %s from the exported image %s", analyzedRegistry, ea.registry)
+		if err := ea.checkAnalyzedRegistry(analyzedMD.Image.Reference); err != nil {
+			return nil, "", err
 		}
 		opts = append(opts, remote.WithPreviousImage(analyzedMD.Image.Reference))
 	}
@@ -344,6 +449,114 @@ func (ea exportArgs) initRemoteAppImage(analyzedMD platform.AnalyzedMetadata) (i
 	return appImage, runImageID.String(), nil
 }
 
+func (ea exportArgs) initCreatedRemoteAppImage(analyzedMD platform.AnalyzedMetadata) (imgutil.Image, string, error) {
+	runImage, runImageID, err := ea.readRemoteRunImage()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var prevImage v1.Image
+	if analyzedMD.Image != nil {
+		cmd.DefaultLogger.Infof("Reusing layers from image '%s'", analyzedMD.Image.Reference)
+		if err := ea.checkAnalyzedRegistry(analyzedMD.Image.Reference); err != nil {
+			return nil, "", err
+		}
+		if prevImage, err = ea.readRemoteImage(analyzedMD.Image.Reference); err != nil {
+			return nil, "", cmd.FailErr(err, "access previous image")
+		}
+	}
+
+	appImage, err := image.NewRegistryImage(ea.imageNames[0], ea.keychain, runImage, prevImage)
+	if err != nil {
+		return nil, "", cmd.FailErr(err, "create new app image")
+	}
+	return appImage, runImageID, nil
+}
+
+func (ea exportArgs) checkAnalyzedRegistry(analyzedRef string) error {
+	ref, err := name.ParseReference(analyzedRef, name.WeakValidation)
+	if err != nil {
+		return cmd.FailErr(err, "parse analyzed registry")
+	}
+	analyzedRegistry := ref.Context().RegistryStr()
+	if analyzedRegistry != ea.registry {
+		return fmt.Errorf("analyzed image is on a different registry %s from the exported image %s", analyzedRegistry, ea.registry)
+	}
+	return nil
+}
+
+// readRemoteRunImage returns the run image and its digest reference.
+func (ea exportArgs) readRemoteRunImage() (v1.Image, string, error) {
+	ref, err := name.ParseReference(ea.runImageRef, name.WeakValidation)
+	if err != nil {
+		return nil, "", cmd.FailErr(err, "parse run image reference")
+	}
+	runImage, err := ea.readRemoteImage(ea.runImageRef)
+	if err != nil {
+		return nil, "", cmd.FailErr(err, "access run image")
+	}
+	runImageDigest, err := runImage.Digest()
+	if err != nil {
+		return nil, "", cmd.FailErr(err, "get run image reference")
+	}
+	return runImage, ref.Context().Digest(runImageDigest.String()).String(), nil
+}
+
+func (ea exportArgs) readRemoteImage(imageRef string) (v1.Image, error) {
+	ref, err := name.ParseReference(imageRef, name.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+	return ggcrremote.Image(ref,
+		ggcrremote.WithAuthFromKeychain(ea.keychain),
+		ggcrremote.WithPlatform(v1.Platform{OS: runtime.GOOS, Architecture: runtime.GOARCH}),
+	)
+}
+
+func (ea exportArgs) initFileAppImage() (imgutil.Image, string, platform.LayersMetadata, error) {
+	runImage, runImageID, err := ea.readRemoteRunImage()
+	if err != nil {
+		return nil, "", platform.LayersMetadata{}, err
+	}
+
+	var (
+		prevImage    v1.Image
+		origMetadata platform.LayersMetadata
+	)
+	if ea.output.Format == image.OutputOCI {
+		prevImage, err = image.ReadLayoutImage(ea.output.Path, ea.imageNames[0])
+		if err != nil {
+			return nil, "", platform.LayersMetadata{}, cmd.FailErr(err, "read previous image")
+		}
+	}
+	if prevImage != nil {
+		cmd.DefaultLogger.Infof("Reusing layers from image '%s' in '%s'", ea.imageNames[0], ea.output.Path)
+		if origMetadata, err = layersMetadataOf(prevImage); err != nil {
+			return nil, "", platform.LayersMetadata{}, cmd.FailErr(err, "read previous image metadata")
+		}
+	}
+
+	appImage, err := image.NewFileImage(ea.imageNames[0], ea.output, runImage, prevImage)
+	if err != nil {
+		return nil, "", platform.LayersMetadata{}, cmd.FailErr(err, "create new app image")
+	}
+	return appImage, runImageID, origMetadata, nil
+}
+
+func layersMetadataOf(img v1.Image) (platform.LayersMetadata, error) {
+	var md platform.LayersMetadata
+	cfg, err := img.ConfigFile()
+	if err != nil {
+		return md, err
+	}
+	if label := cfg.Config.Labels[platform.LayerMetadataLabel]; label != "" {
+		if err := json.Unmarshal([]byte(label), &md); err != nil {
+			return md, errors.Wrapf(err, "failed to unmarshal context of label '%s'", platform.LayerMetadataLabel)
+		}
+	}
+	return md, nil
+}
+
 func launcherConfig(launcherPath string) lifecycle.LauncherConfig {
 	return lifecycle.LauncherConfig{
 		Path: launcherPath,
@@ -404,3 +617,45 @@ func resolveStack(outputImageRef, stackPath, runImageRefOrig string) (platform.S
 
 	return stackMD, runImageRef, registry, nil
 }
+
+// parseCreatedTime parses the -created-time flag, which -layer-created-time requires.
+func parseCreatedTime(createdTime string, layerCreatedTime bool) (time.Time, error) {
+	if createdTime == "" {
+		if layerCreatedTime {
+			return time.Time{}, errors.New("-layer-created-time requires -created-time or SOURCE_DATE_EPOCH")
+		}
+		return time.Time{}, nil
+	}
+	return cmd.ParseCreatedTime(createdTime)
+}
+
+func (ea exportArgs) layerModTime() time.Time {
+	if ea.layerCreatedTime {
+		return ea.created
+	}
+	return time.Time{}
+}
+
+// parseOCILabels parses '<name>=<value>' pairs, where name is the suffix of an org.opencontainers.image label.
+func parseOCILabels(pairs []string) (map[string]string, error) {
+	known := map[string]string{
+		"created":  platform.OCICreatedLabel,
+		"revision": platform.OCIRevisionLabel,
+		"source":   platform.OCISourceLabel,
+		"title":    platform.OCITitleLabel,
+		"version":  platform.OCIVersionLabel,
+	}
+	labels := map[string]string{}
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("label '%s' must have the form '<name>=<value>'", pair)
+		}
+		key, ok := known[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("unknown label name '%s'", parts[0])
+		}
+		labels[key] = parts[1]
+	}
+	return labels, nil
+}
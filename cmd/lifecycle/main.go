@@ -1,6 +1,7 @@
 package main
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,6 +16,12 @@ import (
 )
 
 func main() {
+	if filepath.Base(os.Args[0]) == buildpack.OfflineArg0 {
+		// re-executed in a new network namespace to run a buildpack executable in offline mode
+		// ExecOffline only returns if it fails, after reporting the error to the lifecycle that started it
+		cmd.Exit(cmd.FailErr(buildpack.ExecOffline(os.Args[1:]), "run buildpack in offline mode"))
+	}
+
 	platformAPI := cmd.EnvOrDefault(cmd.EnvPlatformAPI, cmd.DefaultPlatformAPI)
 	if err := cmd.VerifyPlatformAPI(platformAPI); err != nil {
 		cmd.Exit(err)
@@ -46,11 +53,11 @@ func main() {
 		if os.Args[1] == "-version" {
 			cmd.ExitWithVersion()
 		}
-		subcommand()
+		subcommand(platform)
 	}
 }
 
-func subcommand() {
+func subcommand(platform cmd.Platform) {
 	phase := filepath.Base(os.Args[1])
 	switch phase {
 	case "detect":
@@ -67,6 +74,8 @@ func subcommand() {
 		cmd.Run(&rebaseCmd{}, true)
 	case "create":
 		cmd.Run(&createCmd{}, true)
+	case "explain-plan":
+		cmd.Run(&explainPlanCmd{platform: platform}, true)
 	default:
 		cmd.Exit(cmd.FailCode(cmd.CodeInvalidArgs, "unknown phase:", phase))
 	}
@@ -86,6 +95,28 @@ func verifyBuildpackApis(group buildpack.Group) error {
 	return nil
 }
 
+// initBuildpackStore returns a store for the buildpacks in buildpackages, if provided, or else in buildpacksDir.
+// Buildpacks are extracted from buildpackages to a temporary directory that is removed by cleanup.
+func initBuildpackStore(buildpacksDir, buildpackages string) (store lifecycle.BuildpackStore, cleanup func(), err error) {
+	if buildpackages == "" {
+		dirStore, err := buildpack.NewBuildpackStore(buildpacksDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		return dirStore, func() {}, nil
+	}
+	dir, err := ioutil.TempDir("", "lifecycle.buildpacks")
+	if err != nil {
+		return nil, nil, err
+	}
+	pkgStore, err := buildpack.NewPackageBuildpackStore(filepath.SplitList(buildpackages), dir)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	return pkgStore, func() { os.RemoveAll(dir) }, nil
+}
+
 func initCache(cacheImageTag, cacheDir string, keychain authn.Keychain) (lifecycle.Cache, error) {
 	var (
 		cacheStore lifecycle.Cache
@@ -104,3 +135,12 @@ func initCache(cacheImageTag, cacheDir string, keychain authn.Keychain) (lifecyc
 	}
 	return cacheStore, nil
 }
+
+// attributedLogger adapts a cmd.Logger to the lifecycle package, which attributes log records to buildpacks and layers.
+type attributedLogger struct {
+	*cmd.Logger
+}
+
+func (l attributedLogger) WithAttribution(key, value string) lifecycle.Logger {
+	return l.Logger.WithAttribution(key, value)
+}
@@ -1,9 +1,14 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/heroku/color"
@@ -14,6 +19,11 @@ const (
 	warnLevelText  = "Warning: "
 )
 
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
 func init() {
 	// TODO: uncomment when buildpacks/pack#493 (lifecycle containers with a tty) is implemented
 	//color.Disable(!terminal.IsTerminal(int(os.Stdout.Fd())))
@@ -39,9 +49,18 @@ type Logger struct {
 }
 
 func (l *Logger) Phase(name string) {
+	if h, ok := l.Handler.(*jsonHandler); ok {
+		h.setPhase(name)
+	}
 	l.Infof(phaseStyle("===> %s", name))
 }
 
+// WithAttribution returns a logger that attributes its records to the buildpack or layer given by key and value.
+// Attribution is only visible in JSON logs.
+func (l *Logger) WithAttribution(key, value string) *log.Entry {
+	return l.WithField(key, value)
+}
+
 func SetLogLevel(level string) *ErrorFail {
 	var err error
 	DefaultLogger.Level, err = log.ParseLevel(level)
@@ -52,6 +71,31 @@ func SetLogLevel(level string) *ErrorFail {
 	return nil
 }
 
+// SetLogFormat sets the format of the records written by DefaultLogger.
+// In the json format, each record is written as a line of JSON that is attributed to the given phase.
+func SetLogFormat(format, phase string) *ErrorFail {
+	switch format {
+	case LogFormatText:
+		return nil
+	case LogFormatJSON:
+		color.Disable(true)
+		DefaultLogger.Handler = &jsonHandler{writer: Stdout, phase: phaseName(phase)}
+		return nil
+	default:
+		return FailErrCode(fmt.Errorf("unknown log format '%s'", format), CodeInvalidArgs, "parse log format")
+	}
+}
+
+// BuildpackOutput returns the writers for the output of buildpack executables.
+// In the json log format, each line of output is logged as a record that can be attributed to the buildpack.
+func BuildpackOutput() (stdout, stderr io.Writer) {
+	if _, ok := DefaultLogger.Handler.(*jsonHandler); ok {
+		return &lineWriter{entry: DefaultLogger.WithField("stream", "stdout")},
+			&lineWriter{entry: DefaultLogger.WithField("stream", "stderr")}
+	}
+	return Stdout, Stderr
+}
+
 func DisableColor(noColor bool) {
 	Stdout.DisableColors(noColor)
 	Stderr.DisableColors(noColor)
@@ -85,3 +129,103 @@ func appendMissingLineFeed(msg string) string {
 	}
 	return string(buff)
 }
+
+// phaseNames maps lifecycle binaries and subcommands to the phase names used in JSON logs,
+// so that phases run by the creator are named like those run on their own.
+var phaseNames = map[string]string{
+	"analyze":  "analyzing",
+	"analyzer": "analyzing",
+	"build":    "building",
+	"builder":  "building",
+	"create":   "creating",
+	"creator":  "creating",
+	"detect":   "detecting",
+	"detector": "detecting",
+	"export":   "exporting",
+	"exporter": "exporting",
+	"launcher": "launching",
+	"rebase":   "rebasing",
+	"rebaser":  "rebasing",
+	"restore":  "restoring",
+	"restorer": "restoring",
+}
+
+func phaseName(name string) string {
+	name = strings.ToLower(name)
+	if phase, ok := phaseNames[name]; ok {
+		return phase
+	}
+	return name
+}
+
+type jsonHandler struct {
+	mu     sync.Mutex
+	writer io.Writer
+	phase  string
+}
+
+func (h *jsonHandler) setPhase(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.phase = phaseName(name)
+}
+
+func (h *jsonHandler) HandleLog(entry *log.Entry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	record := map[string]interface{}{}
+	for k, v := range entry.Fields {
+		record[k] = v
+	}
+	record["time"] = entry.Timestamp.UTC().Format(time.RFC3339Nano)
+	record["level"] = entry.Level.String()
+	record["phase"] = h.phase
+	record["msg"] = strings.TrimSuffix(entry.Message, "\n")
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = h.writer.Write(append(line, '\n'))
+	return err
+}
+
+// lineWriter logs each line written to it as a record.
+type lineWriter struct {
+	mu    sync.Mutex
+	entry *log.Entry
+	buf   []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.entry.Info(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Close logs the last line written, if it did not end in a line feed.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.entry.Info(string(w.buf))
+		w.buf = nil
+	}
+	return nil
+}
+
+// WithAttribution returns a writer that attributes the lines written to it to the buildpack given by key and value.
+func (w *lineWriter) WithAttribution(key, value string) io.WriteCloser {
+	return &lineWriter{entry: w.entry.WithField(key, value)}
+}
@@ -0,0 +1,118 @@
+package cmd_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/heroku/color"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/cmd"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestLogs(t *testing.T) {
+	spec.Run(t, "Logs", testLogs, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testLogs(t *testing.T, when spec.G, it spec.S) {
+	var (
+		stdout     *color.Console
+		outFile    *os.File
+		origStdout *color.Console
+	)
+
+	it.Before(func() {
+		var err error
+		outFile, err = ioutil.TempFile("", "lifecycle.logs")
+		h.AssertNil(t, err)
+		origStdout = cmd.Stdout
+		stdout = color.NewConsole(outFile)
+		cmd.Stdout = stdout
+		cmd.DefaultLogger = &cmd.Logger{Logger: &log.Logger{Handler: memory.New()}}
+	})
+
+	it.After(func() {
+		cmd.Stdout = origStdout
+		color.Disable(false)
+		outFile.Close()
+		os.Remove(outFile.Name())
+	})
+
+	readRecords := func() []map[string]interface{} {
+		t.Helper()
+		_, err := outFile.Seek(0, io.SeekStart)
+		h.AssertNil(t, err)
+		var records []map[string]interface{}
+		scanner := bufio.NewScanner(outFile)
+		for scanner.Scan() {
+			var record map[string]interface{}
+			h.AssertNil(t, json.Unmarshal(scanner.Bytes(), &record))
+			delete(record, "time")
+			records = append(records, record)
+		}
+		h.AssertNil(t, scanner.Err())
+		return records
+	}
+
+	when("#SetLogFormat", func() {
+		when("the format is json", func() {
+			it.Before(func() {
+				h.AssertNil(t, cmd.SetLogFormat(cmd.LogFormatJSON, "builder"))
+			})
+
+			it("writes each record as a line of JSON", func() {
+				cmd.DefaultLogger.Info("some message\n")
+				cmd.DefaultLogger.WithAttribution("buildpack", "A@v1").Warn("some warning")
+
+				h.AssertEq(t, readRecords(), []map[string]interface{}{
+					{"level": "info", "phase": "building", "msg": "some message"},
+					{"level": "warn", "phase": "building", "msg": "some warning", "buildpack": "A@v1"},
+				})
+			})
+
+			it("attributes records to the current phase", func() {
+				cmd.DefaultLogger.Phase("EXPORTING")
+				cmd.DefaultLogger.Info("some message")
+
+				h.AssertEq(t, readRecords(), []map[string]interface{}{
+					{"level": "info", "phase": "exporting", "msg": "===> EXPORTING"},
+					{"level": "info", "phase": "exporting", "msg": "some message"},
+				})
+			})
+
+			it("logs each line of buildpack output", func() {
+				bpStdout, _ := cmd.BuildpackOutput()
+				w := bpStdout.(interface {
+					WithAttribution(key, value string) io.WriteCloser
+				}).WithAttribution("buildpack", "A@v1")
+
+				_, err := w.Write([]byte("some output\nsome more"))
+				h.AssertNil(t, err)
+				_, err = w.Write([]byte(" output\nlast output"))
+				h.AssertNil(t, err)
+				h.AssertNil(t, w.Close())
+
+				h.AssertEq(t, readRecords(), []map[string]interface{}{
+					{"level": "info", "phase": "building", "msg": "some output", "buildpack": "A@v1", "stream": "stdout"},
+					{"level": "info", "phase": "building", "msg": "some more output", "buildpack": "A@v1", "stream": "stdout"},
+					{"level": "info", "phase": "building", "msg": "last output", "buildpack": "A@v1", "stream": "stdout"},
+				})
+			})
+		})
+
+		when("the format is unknown", func() {
+			it("fails", func() {
+				err := cmd.SetLogFormat("some-format", "builder")
+				h.AssertEq(t, err.Code, cmd.CodeInvalidArgs)
+			})
+		})
+	})
+}
@@ -0,0 +1,199 @@
+package lifecycle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// DetectCache reuses the detect results of previous builds when a buildpack's inputs have not changed.
+// The inputs of a buildpack are its ID and version, the app dir files matching its detect-inputs
+// (or the whole app dir if it declares none), and the platform env.
+type DetectCache struct {
+	AppDir      string
+	PlatformDir string
+	Previous    []platform.DetectCacheEntry
+
+	entries   map[string]platform.DetectCacheEntry // by buildpack
+	inputs    map[string]string                    // hashes by detect-inputs
+	envHash   string
+	envHashed bool
+	mu        sync.Mutex
+}
+
+// Lookup returns the previous detect run for bp if its key is unchanged.
+// The key is returned so that a new run can be recorded.
+func (c *DetectCache) Lookup(bp buildpack.GroupBuildpack, desc *buildpack.Descriptor) (buildpack.DetectRun, string, bool) {
+	key, err := c.key(bp, desc)
+	if err != nil {
+		return buildpack.DetectRun{}, "", false
+	}
+	for _, entry := range c.Previous {
+		if entry.ID == bp.ID && entry.Version == bp.Version && entry.Key == key {
+			run := buildpack.DetectRun{BuildPlan: entry.BuildPlan, Code: entry.Code, Output: []byte(entry.Output)}
+			c.Record(bp, key, run)
+			return run, key, true
+		}
+	}
+	return buildpack.DetectRun{}, key, false
+}
+
+// Record stores the result of running /bin/detect for bp.
+// Only passing or failing runs are recorded, so that errors and timeouts are retried.
+func (c *DetectCache) Record(bp buildpack.GroupBuildpack, key string, run buildpack.DetectRun) {
+	if key == "" || (run.Code != CodeDetectPass && run.Code != CodeDetectFail) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = map[string]platform.DetectCacheEntry{}
+	}
+	c.entries[bp.String()] = platform.DetectCacheEntry{
+		ID:        bp.ID,
+		Version:   bp.Version,
+		Key:       key,
+		BuildPlan: run.BuildPlan,
+		Code:      run.Code,
+		Output:    string(run.Output),
+	}
+}
+
+// Entries returns the entries recorded during this build, sorted by buildpack.
+func (c *DetectCache) Entries() []platform.DetectCacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []platform.DetectCacheEntry
+	for _, entry := range c.entries {
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ID != out[j].ID {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].Version < out[j].Version
+	})
+	return out
+}
+
+func (c *DetectCache) key(bp buildpack.GroupBuildpack, desc *buildpack.Descriptor) (string, error) {
+	inputsHash, err := c.inputsHash(desc.Buildpack.DetectInputs)
+	if err != nil {
+		return "", err
+	}
+	envHash, err := c.platformEnvHash()
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s", bp.ID, bp.Version, inputsHash, envHash)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *DetectCache) inputsHash(patterns []string) (string, error) {
+	id := strings.Join(patterns, "\x00")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hash, ok := c.inputs[id]; ok {
+		return hash, nil
+	}
+
+	roots := []string{c.AppDir}
+	if len(patterns) > 0 {
+		roots = nil
+		for _, pattern := range patterns {
+			matches, err := filepath.Glob(filepath.Join(c.AppDir, pattern))
+			if err != nil {
+				return "", errors.Wrapf(err, "invalid detect input '%s'", pattern)
+			}
+			roots = append(roots, matches...)
+		}
+		sort.Strings(roots)
+	}
+	h := sha256.New()
+	for _, root := range roots {
+		if err := hashTree(h, c.AppDir, root); err != nil {
+			return "", errors.Wrap(err, "hashing detect inputs")
+		}
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+	if c.inputs == nil {
+		c.inputs = map[string]string{}
+	}
+	c.inputs[id] = hash
+	return hash, nil
+}
+
+// hashTree writes the relative path, type and contents of each file below root to h.
+func hashTree(h hash.Hash, base, root string) error {
+	return filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(base, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00", filepath.ToSlash(rel), fi.Mode().String())
+		switch {
+		case fi.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(h, "%s\x00", target)
+		case fi.Mode().IsRegular():
+			f, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if _, err := io.Copy(h, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// platformEnvHash hashes the files in <platform>/env and the stack ID, which are the parts of the environment
+// available to /bin/detect that may differ between builds of the same app.
+func (c *DetectCache) platformEnvHash() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.envHashed {
+		return c.envHash, nil
+	}
+	h := sha256.New()
+	fmt.Fprintf(h, "CNB_STACK_ID=%s\x00", os.Getenv("CNB_STACK_ID"))
+	envDir := filepath.Join(c.PlatformDir, "env")
+	fis, err := ioutil.ReadDir(envDir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", errors.Wrap(err, "reading platform env")
+	}
+	for _, fi := range fis {
+		if fi.IsDir() {
+			continue
+		}
+		contents, err := ioutil.ReadFile(filepath.Join(envDir, fi.Name()))
+		if err != nil {
+			return "", errors.Wrap(err, "reading platform env")
+		}
+		fmt.Fprintf(h, "%s=%s\x00", fi.Name(), contents)
+	}
+	c.envHash, c.envHashed = hex.EncodeToString(h.Sum(nil)), true
+	return c.envHash, nil
+}
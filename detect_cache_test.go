@@ -0,0 +1,146 @@
+package lifecycle_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle"
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestDetectCache(t *testing.T) {
+	spec.Run(t, "DetectCache", testDetectCache, spec.Report(report.Terminal{}))
+}
+
+func testDetectCache(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir      string
+		appDir      string
+		platformDir string
+		bp          = buildpack.GroupBuildpack{ID: "A", Version: "v1"}
+		desc        *buildpack.Descriptor
+		run         = buildpack.DetectRun{
+			BuildPlan: buildpack.BuildPlan{
+				PlanSections: buildpack.PlanSections{Provides: []buildpack.Provide{{Name: "dep1"}}},
+			},
+			Output: []byte("detected"),
+		}
+	)
+
+	// previousBuild records run for bp as a previous build would, returning the entries to be stored in the cache
+	previousBuild := func() []platform.DetectCacheEntry {
+		cache := &lifecycle.DetectCache{AppDir: appDir, PlatformDir: platformDir}
+		_, key, ok := cache.Lookup(bp, desc)
+		h.AssertEq(t, ok, false)
+		cache.Record(bp, key, run)
+		return cache.Entries()
+	}
+
+	lookup := func(previous []platform.DetectCacheEntry) (buildpack.DetectRun, bool) {
+		cache := &lifecycle.DetectCache{AppDir: appDir, PlatformDir: platformDir, Previous: previous}
+		found, _, ok := cache.Lookup(bp, desc)
+		return found, ok
+	}
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.detect-cache")
+		h.AssertNil(t, err)
+		appDir = filepath.Join(tmpDir, "app")
+		platformDir = filepath.Join(tmpDir, "platform")
+		h.Mkdir(t, appDir, filepath.Join(platformDir, "env"))
+		h.Mkfile(t, `{"name": "app"}`, filepath.Join(appDir, "package.json"))
+		h.Mkfile(t, "console.log('hi')", filepath.Join(appDir, "index.js"))
+		desc = &buildpack.Descriptor{Buildpack: buildpack.Info{ID: "A", Version: "v1"}}
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	it("reuses the previous run when nothing has changed", func() {
+		found, ok := lookup(previousBuild())
+		h.AssertEq(t, ok, true)
+		h.AssertEq(t, found, run)
+	})
+
+	it("records reused runs so they are kept in the cache", func() {
+		previous := previousBuild()
+		cache := &lifecycle.DetectCache{AppDir: appDir, PlatformDir: platformDir, Previous: previous}
+		_, _, ok := cache.Lookup(bp, desc)
+		h.AssertEq(t, ok, true)
+		h.AssertEq(t, cache.Entries(), previous)
+	})
+
+	it("does not reuse the run of another buildpack version", func() {
+		previous := previousBuild()
+		bp.Version = "v2"
+		defer func() { bp.Version = "v1" }()
+
+		_, ok := lookup(previous)
+		h.AssertEq(t, ok, false)
+	})
+
+	when("the buildpack does not declare detect inputs", func() {
+		it("does not reuse the run if any app file changed", func() {
+			previous := previousBuild()
+			h.Mkfile(t, "console.log('bye')", filepath.Join(appDir, "index.js"))
+
+			_, ok := lookup(previous)
+			h.AssertEq(t, ok, false)
+		})
+	})
+
+	when("the buildpack declares detect inputs", func() {
+		it.Before(func() {
+			desc.Buildpack.DetectInputs = []string{"package.json", "*.lock"}
+		})
+
+		it("reuses the run if other app files changed", func() {
+			previous := previousBuild()
+			h.Mkfile(t, "console.log('bye')", filepath.Join(appDir, "index.js"))
+
+			_, ok := lookup(previous)
+			h.AssertEq(t, ok, true)
+		})
+
+		it("does not reuse the run if an input changed", func() {
+			previous := previousBuild()
+			h.Mkfile(t, `{"name": "other-app"}`, filepath.Join(appDir, "package.json"))
+
+			_, ok := lookup(previous)
+			h.AssertEq(t, ok, false)
+		})
+
+		it("does not reuse the run if an input was added", func() {
+			previous := previousBuild()
+			h.Mkfile(t, "", filepath.Join(appDir, "yarn.lock"))
+
+			_, ok := lookup(previous)
+			h.AssertEq(t, ok, false)
+		})
+	})
+
+	it("does not reuse the run if the platform env changed", func() {
+		previous := previousBuild()
+		h.Mkfile(t, "true", filepath.Join(platformDir, "env", "BP_DEBUG"))
+
+		_, ok := lookup(previous)
+		h.AssertEq(t, ok, false)
+	})
+
+	it("does not record runs that errored", func() {
+		cache := &lifecycle.DetectCache{AppDir: appDir, PlatformDir: platformDir}
+		_, key, _ := cache.Lookup(bp, desc)
+		cache.Record(bp, key, buildpack.DetectRun{Code: -1})
+		cache.Record(buildpack.GroupBuildpack{ID: "B", Version: "v1"}, key, buildpack.DetectRun{Code: buildpack.CodeDetectTimeout})
+		h.AssertEq(t, len(cache.Entries()), 0)
+	})
+}
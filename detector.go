@@ -3,11 +3,13 @@ package lifecycle
 import (
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/buildpack/semver"
 	"github.com/buildpacks/lifecycle/env"
 	"github.com/buildpacks/lifecycle/platform"
 )
@@ -20,6 +22,7 @@ const (
 var (
 	ErrFailedDetection = errors.New("no buildpacks participating")
 	ErrBuildpack       = errors.New("buildpack(s) failed with err")
+	ErrTimeout         = errors.New("buildpack(s) timed out")
 )
 
 type Resolver interface {
@@ -28,27 +31,33 @@ type Resolver interface {
 
 type Detector struct {
 	buildpack.DetectConfig
-	Platform Platform
-	Resolver Resolver
-	Runs     *sync.Map
-	Store    BuildpackStore
+	UsageReport *platform.UsageReport // optional, records the resources used by each bin/detect when set
+	Cache       *DetectCache          // optional, reuses detect results of previous builds when set
+	Parallelism int                   // maximum number of concurrent bin/detect processes, unlimited when <= 0
+	Platform    Platform
+	Report      *platform.DetectReport
+	Resolver    Resolver
+	Runs        *sync.Map
+	Store       BuildpackStore
+
+	slots    chan struct{}
+	reportMu sync.Mutex
 }
 
-func NewDetector(config buildpack.DetectConfig, buildpacksDir string, platform Platform) (*Detector, error) {
+func NewDetector(config buildpack.DetectConfig, store BuildpackStore, p Platform) *Detector {
+	report := &platform.DetectReport{}
 	resolver := &DefaultResolver{
 		Logger: config.Logger,
-	}
-	store, err := buildpack.NewBuildpackStore(buildpacksDir)
-	if err != nil {
-		return nil, err
+		Report: report,
 	}
 	return &Detector{
 		DetectConfig: config,
-		Platform:     platform,
+		Platform:     p,
+		Report:       report,
 		Resolver:     resolver,
 		Runs:         &sync.Map{},
 		Store:        store,
-	}, nil
+	}
 }
 
 func (d *Detector) Detect(order buildpack.Order) (buildpack.Group, platform.BuildPlan, error) {
@@ -56,12 +65,51 @@ func (d *Detector) Detect(order buildpack.Order) (buildpack.Group, platform.Buil
 }
 
 func (d *Detector) DetectOrder(order buildpack.Order) (buildpack.Group, platform.BuildPlan, error) {
-	bps, entries, err := d.detectOrder(order, nil, nil, false, &sync.WaitGroup{})
-	if err == ErrBuildpack {
-		err = buildpack.NewLifecycleError(err, buildpack.ErrTypeBuildpack)
-	} else if err == ErrFailedDetection {
-		err = buildpack.NewLifecycleError(err, buildpack.ErrTypeFailedDetection)
+	d.initSlots()
+	bps, entries, err := d.detectOrder(order, nil, nil, nil, false, &sync.WaitGroup{})
+	return d.result(bps, entries, lifecycleError(err, err))
+}
+
+// DetectPinned runs detection for a known group instead of traversing an order.
+// Every buildpack in the group must be present in the store and pass detection,
+// so the group must not contain meta-buildpacks.
+func (d *Detector) DetectPinned(group buildpack.Group) (buildpack.Group, platform.BuildPlan, error) {
+	for _, groupBp := range group.Group {
+		bp, err := d.Store.Lookup(groupBp.ID, groupBp.Version)
+		if err != nil {
+			return buildpack.Group{}, platform.BuildPlan{}, errors.Wrapf(err, "lookup pinned buildpack '%s'", groupBp)
+		}
+		if bp.ConfigFile().IsMetaBuildpack() {
+			return buildpack.Group{}, platform.BuildPlan{}, errors.Errorf("pinned buildpack '%s' is a meta-buildpack", groupBp)
+		}
+	}
+
+	d.initSlots()
+	bps, entries, err := d.detectGroup(group, nil, nil, &sync.WaitGroup{})
+	if err == nil {
+		return d.result(bps, entries, nil)
+	}
+	var failed []string
+	for _, groupBp := range group.Group {
+		if t, ok := d.Runs.Load(groupBp.String()); ok && t.(buildpack.DetectRun).Code != CodeDetectPass && !groupBp.Optional {
+			failed = append(failed, groupBp.String())
+		}
+	}
+	cause := errors.Wrap(err, "pinned group does not resolve a build plan")
+	if len(failed) > 0 {
+		cause = errors.Errorf("pinned buildpacks failed detection: %s", strings.Join(failed, ", "))
 	}
+	return d.result(nil, nil, lifecycleError(err, cause))
+}
+
+func (d *Detector) initSlots() {
+	d.slots = nil
+	if d.Parallelism > 0 {
+		d.slots = make(chan struct{}, d.Parallelism)
+	}
+}
+
+func (d *Detector) result(bps []buildpack.GroupBuildpack, entries []platform.BuildPlanEntry, err error) (buildpack.Group, platform.BuildPlan, error) {
 	for i := range entries {
 		for j := range entries[i].Requires {
 			entries[i].Requires[j].ConvertVersionToMetadata()
@@ -70,34 +118,53 @@ func (d *Detector) DetectOrder(order buildpack.Order) (buildpack.Group, platform
 	return buildpack.Group{Group: bps}, platform.BuildPlan{Entries: entries}, err
 }
 
-func (d *Detector) detectOrder(order buildpack.Order, done, next []buildpack.GroupBuildpack, optional bool, wg *sync.WaitGroup) ([]buildpack.GroupBuildpack, []platform.BuildPlanEntry, error) {
+// lifecycleError returns cause as a *buildpack.Error if err is one of the detection errors, or else err unchanged.
+func lifecycleError(err, cause error) error {
+	switch err {
+	case ErrBuildpack:
+		return buildpack.NewLifecycleError(cause, buildpack.ErrTypeBuildpack)
+	case ErrFailedDetection:
+		return buildpack.NewLifecycleError(cause, buildpack.ErrTypeFailedDetection)
+	case ErrTimeout:
+		return buildpack.NewLifecycleError(cause, buildpack.ErrTypeTimeout)
+	}
+	return err
+}
+
+func (d *Detector) detectOrder(order buildpack.Order, done, next []buildpack.GroupBuildpack, trail []metaFrame, optional bool, wg *sync.WaitGroup) ([]buildpack.GroupBuildpack, []platform.BuildPlanEntry, error) {
 	ngroup := buildpack.Group{Group: next}
 	buildpackErr := false
+	timedOut := false
 	for _, group := range order {
 		// FIXME: double-check slice safety here
-		found, plan, err := d.detectGroup(group.Append(ngroup), done, wg)
+		found, plan, err := d.detectGroup(group.Append(ngroup), done, trail, wg)
 		if err == ErrBuildpack {
 			buildpackErr = true
 		}
-		if err == ErrFailedDetection || err == ErrBuildpack {
+		if err == ErrTimeout {
+			timedOut = true
+		}
+		if err == ErrFailedDetection || err == ErrBuildpack || err == ErrTimeout {
 			wg = &sync.WaitGroup{}
 			continue
 		}
 		return found, plan, err
 	}
 	if optional {
-		return d.detectGroup(ngroup, done, wg)
+		return d.detectGroup(ngroup, done, trail, wg)
 	}
 
+	if timedOut {
+		return nil, nil, ErrTimeout
+	}
 	if buildpackErr {
 		return nil, nil, ErrBuildpack
 	}
 	return nil, nil, ErrFailedDetection
 }
 
-func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupBuildpack, wg *sync.WaitGroup) ([]buildpack.GroupBuildpack, []platform.BuildPlanEntry, error) {
+func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupBuildpack, trail []metaFrame, wg *sync.WaitGroup) ([]buildpack.GroupBuildpack, []platform.BuildPlanEntry, error) {
 	for i, groupBp := range group.Group {
-		key := groupBp.String()
 		if hasID(done, groupBp.ID) {
 			continue
 		}
@@ -108,25 +175,33 @@ func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupBuil
 		}
 
 		bpDesc := bp.ConfigFile()
+		if semver.IsConstraint(groupBp.Version) {
+			groupBp.Version = bpDesc.Buildpack.Version
+		}
+		key := groupBp.String()
 		groupBp.API = bpDesc.API
 		groupBp.Homepage = bpDesc.Buildpack.Homepage
 
 		if bpDesc.IsMetaBuildpack() {
+			tail := len(group.Group) - i - 1
+			parents := parentFrames(trail, tail)
+			if err := checkCycle(parents, groupBp); err != nil {
+				return nil, nil, err
+			}
 			// TODO: double-check slice safety here
-			// FIXME: cyclical references lead to infinite recursion
-			return d.detectOrder(bpDesc.Order, done, group.Group[i+1:], groupBp.Optional, wg)
+			return d.detectOrder(bpDesc.Order, done, group.Group[i+1:], append(parents, metaFrame{bp: groupBp, tail: tail}), groupBp.Optional, wg)
 		}
 
 		bpEnv := env.NewBuildEnv(os.Environ(), d.Platform, bp)
 
 		done = append(done, groupBp)
 		wg.Add(1)
-		go func(key string, bp Buildpack) {
+		go func(key string, groupBp buildpack.GroupBuildpack, bp Buildpack) {
 			if _, ok := d.Runs.Load(key); !ok {
-				d.Runs.Store(key, bp.Detect(&d.DetectConfig, bpEnv))
+				d.Runs.Store(key, d.detect(groupBp, bp, bpEnv))
 			}
 			wg.Done()
-		}(key, bp)
+		}(key, groupBp, bp)
 	}
 
 	wg.Wait()
@@ -134,6 +209,80 @@ func (d *Detector) detectGroup(group buildpack.Group, done []buildpack.GroupBuil
 	return d.Resolver.Resolve(done, d.Runs)
 }
 
+func (d *Detector) detect(groupBp buildpack.GroupBuildpack, bp Buildpack, bpEnv buildpack.BuildEnv) buildpack.DetectRun {
+	var cacheKey string
+	if d.Cache != nil {
+		run, key, ok := d.Cache.Lookup(groupBp, bp.ConfigFile())
+		if ok {
+			d.Logger.Debugf("Using cached detect result for %s", groupBp)
+			return run
+		}
+		cacheKey = key
+	}
+	d.acquireSlot()
+	run := bp.Detect(&d.DetectConfig, bpEnv)
+	d.releaseSlot()
+	if d.UsageReport != nil {
+		d.reportMu.Lock()
+		d.UsageReport.Detect = append(d.UsageReport.Detect, newBuildpackUsage(groupBp, run.Duration, run.Usage))
+		d.reportMu.Unlock()
+	}
+	if d.Cache != nil {
+		d.Cache.Record(groupBp, cacheKey, run)
+	}
+	return run
+}
+
+func (d *Detector) acquireSlot() {
+	if d.slots != nil {
+		d.slots <- struct{}{}
+	}
+}
+
+func (d *Detector) releaseSlot() {
+	if d.slots != nil {
+		<-d.slots
+	}
+}
+
+// A metaFrame records a meta-buildpack whose order is being expanded.
+// Expanding a meta-buildpack prepends its order to the remainder of the enclosing group,
+// so tail is the number of entries at the end of each expanded group that do not belong to the meta-buildpack.
+type metaFrame struct {
+	bp   buildpack.GroupBuildpack
+	tail int
+}
+
+// parentFrames returns a copy of the frames in trail that enclose a group entry followed by tail entries.
+func parentFrames(trail []metaFrame, tail int) []metaFrame {
+	var out []metaFrame
+	for _, f := range trail {
+		if tail < f.tail {
+			break
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func checkCycle(parents []metaFrame, bp buildpack.GroupBuildpack) error {
+	for i, f := range parents {
+		if f.bp.ID != bp.ID || f.bp.Version != bp.Version {
+			continue
+		}
+		var chain []string
+		for _, p := range parents[i:] {
+			chain = append(chain, p.bp.String())
+		}
+		chain = append(chain, bp.String())
+		return buildpack.NewLifecycleError(
+			errors.Errorf("cyclic meta-buildpack reference: %s", strings.Join(chain, " -> ")),
+			buildpack.ErrTypeCyclicOrder,
+		)
+	}
+	return nil
+}
+
 func hasID(bps []buildpack.GroupBuildpack, id string) bool {
 	for _, bp := range bps {
 		if bp.ID == id {
@@ -145,11 +294,29 @@ func hasID(bps []buildpack.GroupBuildpack, id string) bool {
 
 type DefaultResolver struct {
 	Logger Logger
+	Report *platform.DetectReport // optional, records every group and plan trial when set
+
+	// VersionConstraints treats the version of each required dependency as a semantic version constraint
+	// that must be satisfied by one of the versions offered by its providers.
+	VersionConstraints bool
 }
 
 // Resolve aggregates the detect output for a group of buildpacks and tries to resolve a build plan for the group.
 // If any required buildpack in the group failed detection or a build plan cannot be resolved, it returns an error.
 func (r *DefaultResolver) Resolve(done []buildpack.GroupBuildpack, detectRuns *sync.Map) ([]buildpack.GroupBuildpack, []platform.BuildPlanEntry, error) {
+	groupReport := platform.DetectGroupReport{}
+	found, plan, err := r.resolve(done, detectRuns, &groupReport)
+	if r.Report != nil {
+		groupReport.Pass = err == nil
+		if err != nil {
+			groupReport.Error = err.Error()
+		}
+		r.Report.Groups = append(r.Report.Groups, groupReport)
+	}
+	return found, plan, err
+}
+
+func (r *DefaultResolver) resolve(done []buildpack.GroupBuildpack, detectRuns *sync.Map, groupReport *platform.DetectGroupReport) ([]buildpack.GroupBuildpack, []platform.BuildPlanEntry, error) {
 	var groupRuns []buildpack.DetectRun
 	for _, bp := range done {
 		t, ok := detectRuns.Load(bp.String())
@@ -157,12 +324,13 @@ func (r *DefaultResolver) Resolve(done []buildpack.GroupBuildpack, detectRuns *s
 			return nil, nil, errors.Errorf("missing detection of '%s'", bp)
 		}
 		run := t.(buildpack.DetectRun)
-		outputLogf := r.Logger.Debugf
+		bpLogger := withAttribution(r.Logger, "buildpack", bp.String())
+		outputLogf := bpLogger.Debugf
 
 		switch run.Code {
 		case CodeDetectPass, CodeDetectFail:
 		default:
-			outputLogf = r.Logger.Infof
+			outputLogf = bpLogger.Infof
 		}
 
 		if len(run.Output) > 0 {
@@ -173,6 +341,10 @@ func (r *DefaultResolver) Resolve(done []buildpack.GroupBuildpack, detectRuns *s
 			outputLogf("======== Error: %s ========", bp)
 			outputLogf(run.Err.Error())
 		}
+		if run.Code == buildpack.CodeDetectOfflineFailed {
+			// a failure of the lifecycle rather than the buildpack, so detection cannot continue
+			return nil, nil, run.Err
+		}
 		groupRuns = append(groupRuns, run)
 	}
 
@@ -181,30 +353,46 @@ func (r *DefaultResolver) Resolve(done []buildpack.GroupBuildpack, detectRuns *s
 	results := detectResults{}
 	detected := true
 	buildpackErr := false
+	timedOut := false
 	for i, bp := range done {
 		run := groupRuns[i]
+		bpReport := newDetectBuildpackReport(bp, run)
 		switch run.Code {
 		case CodeDetectPass:
 			r.Logger.Debugf("pass: %s", bp)
+			bpReport.Result = "pass"
 			results = append(results, detectResult{bp, run})
 		case CodeDetectFail:
 			if bp.Optional {
 				r.Logger.Debugf("skip: %s", bp)
+				bpReport.Result = "skip"
 			} else {
 				r.Logger.Debugf("fail: %s", bp)
+				bpReport.Result = "fail"
 			}
 			detected = detected && bp.Optional
+		case buildpack.CodeDetectTimeout:
+			r.Logger.Infof("timeout: %s", bp)
+			bpReport.Result = "timeout"
+			timedOut = true
+			detected = detected && bp.Optional
 		case -1:
 			r.Logger.Infof("err:  %s", bp)
+			bpReport.Result = "error"
 			buildpackErr = true
 			detected = detected && bp.Optional
 		default:
 			r.Logger.Infof("err:  %s (%d)", bp, run.Code)
+			bpReport.Result = "error"
 			buildpackErr = true
 			detected = detected && bp.Optional
 		}
+		groupReport.Buildpacks = append(groupReport.Buildpacks, bpReport)
 	}
 	if !detected {
+		if timedOut {
+			return nil, nil, ErrTimeout
+		}
 		if buildpackErr {
 			return nil, nil, ErrBuildpack
 		}
@@ -214,7 +402,11 @@ func (r *DefaultResolver) Resolve(done []buildpack.GroupBuildpack, detectRuns *s
 	i := 0
 	deps, trial, err := results.runTrials(func(trial detectTrial) (depMap, detectTrial, error) {
 		i++
-		return r.runTrial(i, trial)
+		trialReport := platform.DetectTrialReport{Buildpacks: trial.buildpacks()}
+		deps, trial, err := r.runTrial(i, trial, &trialReport)
+		trialReport.Pass = err == nil
+		groupReport.Trials = append(groupReport.Trials, trialReport)
+		return deps, trial, err
 	})
 	if err != nil {
 		return nil, nil, err
@@ -249,7 +441,7 @@ func (r *DefaultResolver) Resolve(done []buildpack.GroupBuildpack, detectRuns *s
 	return found, plan, nil
 }
 
-func (r *DefaultResolver) runTrial(i int, trial detectTrial) (depMap, detectTrial, error) {
+func (r *DefaultResolver) runTrial(i int, trial detectTrial, trialReport *platform.DetectTrialReport) (depMap, detectTrial, error) {
 	r.Logger.Debugf("Resolving plan... (try #%d)", i)
 
 	var deps depMap
@@ -260,24 +452,50 @@ func (r *DefaultResolver) runTrial(i int, trial detectTrial) (depMap, detectTria
 
 		if err := deps.eachUnmetRequire(func(name string, bp buildpack.GroupBuildpack) error {
 			retry = true
+			reason := fmt.Sprintf("%s requires %s", bp, name)
 			if !bp.Optional {
-				r.Logger.Debugf("fail: %s requires %s", bp, name)
+				r.Logger.Debugf("fail: %s", reason)
+				trialReport.Error = reason
 				return ErrFailedDetection
 			}
-			r.Logger.Debugf("skip: %s requires %s", bp, name)
+			r.Logger.Debugf("skip: %s", reason)
+			trialReport.Skipped = append(trialReport.Skipped, reason)
 			trial = trial.remove(bp)
 			return nil
 		}); err != nil {
 			return nil, nil, err
 		}
 
+		if r.VersionConstraints && !retry {
+			if err := deps.eachUnsatisfiedConstraint(func(name string, bp buildpack.GroupBuildpack, reason string) error {
+				retry = true
+				if !bp.Optional {
+					r.Logger.Debugf("fail: %s", reason)
+					trialReport.Error = reason
+					return ErrFailedDetection
+				}
+				r.Logger.Debugf("skip: %s", reason)
+				trialReport.Skipped = append(trialReport.Skipped, reason)
+				trial = trial.remove(bp)
+				return nil
+			}); err != nil {
+				return nil, nil, err
+			}
+			if retry {
+				continue
+			}
+		}
+
 		if err := deps.eachUnmetProvide(func(name string, bp buildpack.GroupBuildpack) error {
 			retry = true
+			reason := fmt.Sprintf("%s provides unused %s", bp, name)
 			if !bp.Optional {
-				r.Logger.Debugf("fail: %s provides unused %s", bp, name)
+				r.Logger.Debugf("fail: %s", reason)
+				trialReport.Error = reason
 				return ErrFailedDetection
 			}
-			r.Logger.Debugf("skip: %s provides unused %s", bp, name)
+			r.Logger.Debugf("skip: %s", reason)
+			trialReport.Skipped = append(trialReport.Skipped, reason)
 			trial = trial.remove(bp)
 			return nil
 		}); err != nil {
@@ -287,11 +505,26 @@ func (r *DefaultResolver) runTrial(i int, trial detectTrial) (depMap, detectTria
 
 	if len(trial) == 0 {
 		r.Logger.Debugf("fail: no viable buildpacks in group")
+		trialReport.Error = "no viable buildpacks in group"
 		return nil, nil, ErrFailedDetection
 	}
 	return deps, trial, nil
 }
 
+func newDetectBuildpackReport(bp buildpack.GroupBuildpack, run buildpack.DetectRun) platform.DetectBuildpackReport {
+	report := platform.DetectBuildpackReport{
+		GroupBuildpack: bp,
+		BuildPlan:      run.BuildPlan,
+		ExitCode:       run.Code,
+		Output:         string(run.Output),
+		DurationMS:     run.Duration.Milliseconds(),
+	}
+	if run.Err != nil {
+		report.Error = run.Err.Error()
+	}
+	return report
+}
+
 type detectResult struct {
 	buildpack.GroupBuildpack
 	buildpack.DetectRun
@@ -338,6 +571,14 @@ type detectOption struct {
 
 type detectTrial []detectOption
 
+func (ts detectTrial) buildpacks() []buildpack.GroupBuildpack {
+	var out []buildpack.GroupBuildpack
+	for _, t := range ts {
+		out = append(out, t.GroupBuildpack)
+	}
+	return out
+}
+
 func (ts detectTrial) remove(bp buildpack.GroupBuildpack) detectTrial {
 	var out detectTrial
 	for _, t := range ts {
@@ -352,6 +593,31 @@ type depEntry struct {
 	platform.BuildPlanEntry
 	earlyRequires []buildpack.GroupBuildpack
 	extraProvides []buildpack.GroupBuildpack
+
+	offered          providedVersions // by Providers
+	extraOffered     providedVersions // by extraProvides
+	constrainedNeeds []versionNeed
+}
+
+// providedVersions are the versions of a dependency offered by a set of providers.
+// A provider that does not declare versions may provide any version.
+type providedVersions struct {
+	versions []string
+	any      bool
+}
+
+func (p providedVersions) add(o providedVersions) providedVersions {
+	return providedVersions{
+		versions: append(append([]string{}, p.versions...), o.versions...),
+		any:      p.any || o.any,
+	}
+}
+
+// A versionNeed records a require along with the versions offered by the providers preceding it.
+type versionNeed struct {
+	bp      buildpack.GroupBuildpack
+	require buildpack.Require
+	offered providedVersions
 }
 
 type depMap map[string]depEntry
@@ -372,6 +638,7 @@ func newDepMap(trial detectTrial) depMap {
 func (m depMap) provide(bp buildpack.GroupBuildpack, provide buildpack.Provide) {
 	entry := m[provide.Name]
 	entry.extraProvides = append(entry.extraProvides, bp)
+	entry.extraOffered = entry.extraOffered.add(providedVersions{versions: provide.Versions, any: len(provide.Versions) == 0})
 	m[provide.Name] = entry
 }
 
@@ -379,11 +646,14 @@ func (m depMap) require(bp buildpack.GroupBuildpack, require buildpack.Require)
 	entry := m[require.Name]
 	entry.Providers = append(entry.Providers, entry.extraProvides...)
 	entry.extraProvides = nil
+	entry.offered = entry.offered.add(entry.extraOffered)
+	entry.extraOffered = providedVersions{}
 
 	if len(entry.Providers) == 0 {
 		entry.earlyRequires = append(entry.earlyRequires, bp)
 	} else {
 		entry.Requires = append(entry.Requires, require)
+		entry.constrainedNeeds = append(entry.constrainedNeeds, versionNeed{bp: bp, require: require, offered: entry.offered})
 	}
 	m[require.Name] = entry
 }
@@ -413,3 +683,44 @@ func (m depMap) eachUnmetRequire(f func(name string, bp buildpack.GroupBuildpack
 	}
 	return nil
 }
+
+func (m depMap) eachUnsatisfiedConstraint(f func(name string, bp buildpack.GroupBuildpack, reason string) error) error {
+	for name, entry := range m {
+		for _, need := range entry.constrainedNeeds {
+			if reason := need.unsatisfied(); reason != "" {
+				if err := f(name, need.bp, reason); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// unsatisfied returns an explanation if none of the offered versions satisfy the version required, or else the empty string.
+func (n versionNeed) unsatisfied() string {
+	required := requiredVersion(n.require)
+	if required == "" || n.offered.any {
+		return ""
+	}
+	constraint, err := semver.NewConstraint(required)
+	if err != nil {
+		return fmt.Sprintf("%s requires %s with invalid version constraint '%s'", n.bp, n.require.Name, required)
+	}
+	for _, offered := range n.offered.versions {
+		if v, err := semver.NewVersion(offered); err == nil && constraint.Check(v) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s requires %s version '%s' but only [%s] is provided", n.bp, n.require.Name, required, strings.Join(n.offered.versions, ", "))
+}
+
+func requiredVersion(r buildpack.Require) string {
+	if r.Version != "" {
+		return r.Version
+	}
+	if version, ok := r.Metadata["version"]; ok {
+		return fmt.Sprintf("%v", version)
+	}
+	return ""
+}
@@ -1,10 +1,14 @@
 package lifecycle_test
 
 import (
+	"io/ioutil"
+	"os"
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
@@ -492,6 +496,90 @@ func testDetector(t *testing.T, when spec.G, it spec.S) {
 			}
 		})
 
+		it("should record the resolved version of buildpacks referenced by version range", func() {
+			bpA1 := testmock.NewMockBuildpack(mockCtrl)
+			buildpackStore.EXPECT().Lookup("A", "^1.0").Return(bpA1, nil)
+			bpA1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{
+				API:       "0.3",
+				Buildpack: buildpack.Info{ID: "A", Version: "1.2.3"},
+			})
+			bpA1.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+			bpA1.EXPECT().Detect(gomock.Any(), gomock.Any())
+
+			group := []buildpack.GroupBuildpack{{ID: "A", Version: "1.2.3", API: "0.3"}}
+			resolver.EXPECT().Resolve(group, detector.Runs).Return(group, nil, nil)
+
+			found, _, err := detector.Detect(buildpack.Order{
+				{Group: []buildpack.GroupBuildpack{{ID: "A", Version: "^1.0"}}},
+			})
+			h.AssertNil(t, err)
+			h.AssertEq(t, found, buildpack.Group{Group: group})
+
+			if _, ok := detector.Runs.Load("A@1.2.3"); !ok {
+				t.Fatalf("Expected detect run to be recorded for resolved version")
+			}
+		})
+
+		when("a detect cache is provided", func() {
+			it("should reuse cached runs instead of running /bin/detect", func() {
+				appDir, err := ioutil.TempDir("", "lifecycle.detector")
+				h.AssertNil(t, err)
+				defer os.RemoveAll(appDir)
+
+				bpA1 := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA1, nil).Times(2)
+				bpA1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{API: "0.3"}).AnyTimes()
+				bpA1.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+				run := buildpack.DetectRun{Output: []byte("detected")}
+				bpA1.EXPECT().Detect(gomock.Any(), gomock.Any()).Return(run) // only once
+
+				group := []buildpack.GroupBuildpack{{ID: "A", Version: "v1", API: "0.3"}}
+				resolver.EXPECT().Resolve(group, gomock.Any()).Return(group, nil, nil).Times(2)
+
+				detector.Logger = &log.Logger{Handler: memory.New()}
+				detector.Cache = &lifecycle.DetectCache{AppDir: appDir}
+				_, _, err = detector.Detect(buildpack.Order{{Group: group}})
+				h.AssertNil(t, err)
+
+				detector.Cache = &lifecycle.DetectCache{AppDir: appDir, Previous: detector.Cache.Entries()}
+				detector.Runs = &sync.Map{}
+				_, _, err = detector.Detect(buildpack.Order{{Group: group}})
+				h.AssertNil(t, err)
+
+				cached, ok := detector.Runs.Load("A@v1")
+				h.AssertEq(t, ok, true)
+				h.AssertEq(t, cached, run)
+			})
+		})
+
+		when("a usage report is provided", func() {
+			it("should record the resources used by each /bin/detect", func() {
+				detector.UsageReport = &platform.UsageReport{}
+				bpA1 := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA1, nil)
+				bpA1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{API: "0.3"}).AnyTimes()
+				bpA1.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+				bpA1.EXPECT().Detect(gomock.Any(), gomock.Any()).Return(buildpack.DetectRun{
+					Duration: 3 * time.Second,
+					Usage:    buildpack.Usage{UserTime: 2 * time.Second, SysTime: time.Second, MaxRSS: 1024},
+				})
+
+				group := []buildpack.GroupBuildpack{{ID: "A", Version: "v1", API: "0.3"}}
+				resolver.EXPECT().Resolve(group, gomock.Any()).Return(group, nil, nil)
+
+				_, _, err := detector.Detect(buildpack.Order{{Group: group}})
+				h.AssertNil(t, err)
+				h.AssertEq(t, detector.UsageReport.Detect, []platform.BuildpackUsage{{
+					ID:         "A",
+					Version:    "v1",
+					WallTimeMS: 3000,
+					UserTimeMS: 2000,
+					SysTimeMS:  1000,
+					MaxRSSKB:   1024,
+				}})
+			})
+		})
+
 		it("should update detect runs for each buildpack", func() {
 			bpA1 := testmock.NewMockBuildpack(mockCtrl)
 			buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA1, nil)
@@ -573,6 +661,157 @@ func testDetector(t *testing.T, when spec.G, it spec.S) {
 			}
 		})
 
+		when("parallelism is limited", func() {
+			it("should not run more bin/detect processes than allowed at once", func() {
+				detector.Parallelism = 2
+
+				var group []buildpack.GroupBuildpack
+				var running, maxRunning int32
+				for _, id := range []string{"A", "B", "C", "D", "E", "F"} {
+					bp := testmock.NewMockBuildpack(mockCtrl)
+					bp.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+					buildpackStore.EXPECT().Lookup(id, "v1").Return(bp, nil)
+					bp.EXPECT().ConfigFile().Return(&buildpack.Descriptor{API: "0.3"})
+					bp.EXPECT().Detect(gomock.Any(), gomock.Any()).DoAndReturn(func(_ *buildpack.DetectConfig, _ buildpack.BuildEnv) buildpack.DetectRun {
+						n := atomic.AddInt32(&running, 1)
+						for {
+							max := atomic.LoadInt32(&maxRunning)
+							if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+								break
+							}
+						}
+						time.Sleep(10 * time.Millisecond)
+						atomic.AddInt32(&running, -1)
+						return buildpack.DetectRun{}
+					})
+					group = append(group, buildpack.GroupBuildpack{ID: id, Version: "v1", API: "0.3"})
+				}
+				resolver.EXPECT().Resolve(group, detector.Runs).Return(group, []platform.BuildPlanEntry{}, nil)
+
+				_, _, err := detector.Detect(buildpack.Order{{Group: group}})
+				h.AssertNil(t, err)
+
+				if max := atomic.LoadInt32(&maxRunning); max > 2 {
+					t.Fatalf("Expected at most 2 concurrent detects, got %d", max)
+				}
+			})
+		})
+
+		when("meta-buildpack orders reference each other", func() {
+			it("returns a cyclic order error with the reference chain", func() {
+				bpA1 := testmock.NewMockBuildpack(mockCtrl)
+				bpB2 := testmock.NewMockBuildpack(mockCtrl)
+
+				bpAOrder := &buildpack.Descriptor{
+					API:   "0.3",
+					Order: []buildpack.Group{{Group: []buildpack.GroupBuildpack{{ID: "B", Version: "v2"}}}},
+				}
+				bpBOrder := &buildpack.Descriptor{
+					API:   "0.3",
+					Order: []buildpack.Group{{Group: []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}}}},
+				}
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA1, nil).Times(2)
+				bpA1.EXPECT().ConfigFile().Return(bpAOrder).Times(2)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB2, nil)
+				bpB2.EXPECT().ConfigFile().Return(bpBOrder)
+
+				_, _, err := detector.Detect(buildpack.Order{{Group: []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}}}})
+				if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeCyclicOrder {
+					t.Fatalf("Unexpected error:\n%s\n", err)
+				}
+				h.AssertEq(t, err.Error(), "cyclic meta-buildpack reference: A@v1 -> B@v2 -> A@v1")
+			})
+
+			it("allows a meta-buildpack to follow itself in a group", func() {
+				bpA1 := testmock.NewMockBuildpack(mockCtrl)
+				bpB1 := testmock.NewMockBuildpack(mockCtrl)
+				bpB1.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+
+				bpAOrder := &buildpack.Descriptor{
+					API:   "0.3",
+					Order: []buildpack.Group{{Group: []buildpack.GroupBuildpack{{ID: "B", Version: "v1"}}}},
+				}
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA1, nil).Times(2)
+				bpA1.EXPECT().ConfigFile().Return(bpAOrder).Times(2)
+				buildpackStore.EXPECT().Lookup("B", "v1").Return(bpB1, nil)
+				bpB1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{API: "0.3"})
+				bpB1.EXPECT().Detect(gomock.Any(), gomock.Any())
+
+				group := []buildpack.GroupBuildpack{{ID: "B", Version: "v1", API: "0.3"}}
+				resolver.EXPECT().Resolve(group, detector.Runs).Return(group, []platform.BuildPlanEntry{}, nil)
+
+				_, _, err := detector.Detect(buildpack.Order{{Group: []buildpack.GroupBuildpack{
+					{ID: "A", Version: "v1"},
+					{ID: "A", Version: "v1"},
+				}}})
+				h.AssertNil(t, err)
+			})
+		})
+
+		when("#DetectPinned", func() {
+			var (
+				bpA1, bpB1 *testmock.MockBuildpack
+				group      []buildpack.GroupBuildpack
+			)
+
+			it.Before(func() {
+				bpA1 = testmock.NewMockBuildpack(mockCtrl)
+				bpA1.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+				bpB1 = testmock.NewMockBuildpack(mockCtrl)
+				bpB1.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA1, nil).AnyTimes()
+				buildpackStore.EXPECT().Lookup("B", "v1").Return(bpB1, nil).AnyTimes()
+				group = []buildpack.GroupBuildpack{
+					{ID: "A", Version: "v1", API: "0.3"},
+					{ID: "B", Version: "v1", API: "0.3"},
+				}
+			})
+
+			it("detects the pinned group without an order", func() {
+				bpA1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{API: "0.3"}).Times(2)
+				bpA1.EXPECT().Detect(gomock.Any(), gomock.Any()).Return(buildpack.DetectRun{})
+				bpB1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{API: "0.3"}).Times(2)
+				bpB1.EXPECT().Detect(gomock.Any(), gomock.Any()).Return(buildpack.DetectRun{})
+				resolver.EXPECT().Resolve(group, detector.Runs).Return(group, nil, nil)
+
+				found, _, err := detector.DetectPinned(buildpack.Group{Group: group})
+				h.AssertNil(t, err)
+				h.AssertEq(t, found, buildpack.Group{Group: group})
+			})
+
+			it("fails naming the pinned buildpacks that no longer pass detection", func() {
+				bpA1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{API: "0.3"}).Times(2)
+				bpA1.EXPECT().Detect(gomock.Any(), gomock.Any()).Return(buildpack.DetectRun{})
+				bpB1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{API: "0.3"}).Times(2)
+				bpB1.EXPECT().Detect(gomock.Any(), gomock.Any()).Return(buildpack.DetectRun{Code: lifecycle.CodeDetectFail})
+				resolver.EXPECT().Resolve(group, detector.Runs).Return(nil, nil, lifecycle.ErrFailedDetection)
+
+				_, _, err := detector.DetectPinned(buildpack.Group{Group: group})
+				if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeFailedDetection {
+					t.Fatalf("Unexpected error:\n%s\n", err)
+				}
+				h.AssertError(t, err, "pinned buildpacks failed detection: B@v1")
+			})
+
+			it("fails if a pinned buildpack is a meta-buildpack", func() {
+				bpA1.EXPECT().ConfigFile().Return(&buildpack.Descriptor{
+					API:   "0.3",
+					Order: buildpack.Order{{Group: []buildpack.GroupBuildpack{{ID: "B", Version: "v1"}}}},
+				})
+
+				_, _, err := detector.DetectPinned(buildpack.Group{Group: group})
+				h.AssertError(t, err, "pinned buildpack 'A@v1' is a meta-buildpack")
+			})
+
+			it("fails if a pinned buildpack is missing from the store", func() {
+				missing := []buildpack.GroupBuildpack{{ID: "C", Version: "v1"}}
+				buildpackStore.EXPECT().Lookup("C", "v1").Return(nil, errors.New("not found"))
+
+				_, _, err := detector.DetectPinned(buildpack.Group{Group: missing})
+				h.AssertError(t, err, "lookup pinned buildpack 'C@v1': not found")
+			})
+		})
+
 		when("resolve errors", func() {
 			when("with buildpack error", func() {
 				it("returns a buildpack error", func() {
@@ -710,6 +949,56 @@ func testDetector(t *testing.T, when spec.G, it spec.S) {
 			}
 		})
 
+		it("should fail with a timeout error if any bp detect timed out", func() {
+			group := []buildpack.GroupBuildpack{
+				{ID: "A", Version: "v1", Optional: false},
+				{ID: "B", Version: "v1", Optional: false},
+			}
+
+			detectRuns := &sync.Map{}
+			detectRuns.Store("A@v1", buildpack.DetectRun{
+				Code: 127,
+			})
+			detectRuns.Store("B@v1", buildpack.DetectRun{
+				Code: buildpack.CodeDetectTimeout,
+			})
+
+			_, _, err := resolver.Resolve(group, detectRuns)
+			if err != lifecycle.ErrTimeout {
+				t.Fatalf("Unexpected error:\n%s\n", err)
+			}
+
+			if s := h.AllLogs(logHandler); !strings.HasSuffix(s,
+				"======== Results ========\n"+
+					"err:  A@v1 (127)\n"+
+					"timeout: B@v1\n",
+			) {
+				t.Fatalf("Unexpected log:\n%s\n", s)
+			}
+		})
+
+		it("should fail with the lifecycle error if any bp could not be run in offline mode", func() {
+			group := []buildpack.GroupBuildpack{
+				{ID: "A", Version: "v1", Optional: false},
+				{ID: "B", Version: "v1", Optional: true},
+			}
+
+			detectRuns := &sync.Map{}
+			detectRuns.Store("A@v1", buildpack.DetectRun{
+				Code: 100,
+			})
+			detectRuns.Store("B@v1", buildpack.DetectRun{
+				Code: buildpack.CodeDetectOfflineFailed,
+				Err:  errors.New("failed to run buildpack B in offline mode"),
+			})
+
+			_, _, err := resolver.Resolve(group, detectRuns)
+			if _, ok := err.(*buildpack.Error); ok || err == lifecycle.ErrBuildpack {
+				t.Fatalf("Unexpected error:\n%s\n", err)
+			}
+			h.AssertError(t, err, "failed to run buildpack B in offline mode")
+		})
+
 		it("should not output detect pass and fail as info level", func() {
 			group := []buildpack.GroupBuildpack{
 				{ID: "A", Version: "v1", Optional: false},
@@ -1057,6 +1346,212 @@ func testDetector(t *testing.T, when spec.G, it spec.S) {
 			}
 		})
 
+		when("version constraints are enabled", func() {
+			var (
+				group      []buildpack.GroupBuildpack
+				detectRuns *sync.Map
+			)
+
+			storeRequire := func(constraints ...string) {
+				var sections []buildpack.PlanSections
+				for _, c := range constraints {
+					sections = append(sections, buildpack.PlanSections{
+						Requires: []buildpack.Require{{Name: "dep1", Metadata: map[string]interface{}{"version": c}}},
+					})
+				}
+				detectRuns.Store("B@v1", buildpack.DetectRun{
+					BuildPlan: buildpack.BuildPlan{PlanSections: sections[0], Or: sections[1:]},
+				})
+			}
+
+			it.Before(func() {
+				resolver.VersionConstraints = true
+				group = []buildpack.GroupBuildpack{
+					{ID: "A", Version: "v1"},
+					{ID: "B", Version: "v1"},
+				}
+				detectRuns = &sync.Map{}
+				detectRuns.Store("A@v1", buildpack.DetectRun{
+					BuildPlan: buildpack.BuildPlan{
+						PlanSections: buildpack.PlanSections{
+							Provides: []buildpack.Provide{{Name: "dep1", Versions: []string{"1.2.0", "2.1.0"}}},
+						},
+					},
+				})
+			})
+
+			it("should succeed if a provided version satisfies the required constraint", func() {
+				storeRequire("^2.0")
+
+				found, plan, err := resolver.Resolve(group, detectRuns)
+				h.AssertNil(t, err)
+				h.AssertEq(t, found, group)
+				h.AssertEq(t, len(plan), 1)
+				h.AssertEq(t, plan[0].Requires[0].Metadata["version"], "^2.0")
+			})
+
+			it("should explain which constraint failed", func() {
+				storeRequire("^3.0")
+
+				_, _, err := resolver.Resolve(group, detectRuns)
+				if err != lifecycle.ErrFailedDetection {
+					t.Fatalf("Unexpected error:\n%s\n", err)
+				}
+				h.AssertStringContains(t, h.AllLogs(logHandler), "fail: B@v1 requires dep1 version '^3.0' but only [1.2.0, 2.1.0] is provided\n")
+			})
+
+			it("should fallback to alternate build plans", func() {
+				storeRequire("^3.0", "~1.2")
+
+				found, _, err := resolver.Resolve(group, detectRuns)
+				h.AssertNil(t, err)
+				h.AssertEq(t, found, group)
+			})
+
+			it("should skip optional buildpacks with unsatisfied constraints", func() {
+				group = []buildpack.GroupBuildpack{
+					{ID: "A", Version: "v1"},
+					{ID: "B", Version: "v1", Optional: true},
+					{ID: "C", Version: "v1"},
+				}
+				storeRequire(">=3")
+				detectRuns.Store("C@v1", buildpack.DetectRun{
+					BuildPlan: buildpack.BuildPlan{
+						PlanSections: buildpack.PlanSections{
+							Requires: []buildpack.Require{{Name: "dep1", Version: "1.2.0"}},
+						},
+					},
+				})
+
+				found, _, err := resolver.Resolve(group, detectRuns)
+				h.AssertNil(t, err)
+				h.AssertEq(t, found, []buildpack.GroupBuildpack{{ID: "A", Version: "v1"}, {ID: "C", Version: "v1"}})
+				h.AssertStringContains(t, h.AllLogs(logHandler), "skip: B@v1 requires dep1 version '>=3' but only [1.2.0, 2.1.0] is provided\n")
+			})
+
+			it("should accept any version from providers that do not declare versions", func() {
+				detectRuns.Store("A@v1", buildpack.DetectRun{
+					BuildPlan: buildpack.BuildPlan{
+						PlanSections: buildpack.PlanSections{
+							Provides: []buildpack.Provide{{Name: "dep1"}},
+						},
+					},
+				})
+				storeRequire("^3.0")
+
+				_, _, err := resolver.Resolve(group, detectRuns)
+				h.AssertNil(t, err)
+			})
+
+			it("should ignore constraints when disabled", func() {
+				resolver.VersionConstraints = false
+				storeRequire("^3.0")
+
+				_, _, err := resolver.Resolve(group, detectRuns)
+				h.AssertNil(t, err)
+			})
+		})
+
+		when("a report is provided", func() {
+			it.Before(func() {
+				resolver.Report = &platform.DetectReport{}
+			})
+
+			it("should record each buildpack result and plan trial", func() {
+				group := []buildpack.GroupBuildpack{
+					{ID: "A", Version: "v1"},
+					{ID: "B", Version: "v1", Optional: true},
+				}
+
+				detectRuns := &sync.Map{}
+				detectRuns.Store("A@v1", buildpack.DetectRun{
+					BuildPlan: buildpack.BuildPlan{
+						PlanSections: buildpack.PlanSections{
+							Requires: []buildpack.Require{{Name: "dep-missing"}},
+						},
+						Or: []buildpack.PlanSections{
+							{Provides: []buildpack.Provide{{Name: "dep-present"}}, Requires: []buildpack.Require{{Name: "dep-present"}}},
+						},
+					},
+					Output:   []byte("detect out: A@v1"),
+					Duration: 1500 * time.Millisecond,
+				})
+				detectRuns.Store("B@v1", buildpack.DetectRun{})
+
+				_, _, err := resolver.Resolve(group, detectRuns)
+				h.AssertNil(t, err)
+
+				if s := cmp.Diff(resolver.Report, &platform.DetectReport{
+					Groups: []platform.DetectGroupReport{{
+						Buildpacks: []platform.DetectBuildpackReport{
+							{
+								GroupBuildpack: buildpack.GroupBuildpack{ID: "A", Version: "v1"},
+								BuildPlan: buildpack.BuildPlan{
+									PlanSections: buildpack.PlanSections{
+										Requires: []buildpack.Require{{Name: "dep-missing"}},
+									},
+									Or: []buildpack.PlanSections{
+										{Provides: []buildpack.Provide{{Name: "dep-present"}}, Requires: []buildpack.Require{{Name: "dep-present"}}},
+									},
+								},
+								Result:     "pass",
+								Output:     "detect out: A@v1",
+								DurationMS: 1500,
+							},
+							{
+								GroupBuildpack: buildpack.GroupBuildpack{ID: "B", Version: "v1", Optional: true},
+								Result:         "pass",
+							},
+						},
+						Trials: []platform.DetectTrialReport{
+							{
+								Buildpacks: []buildpack.GroupBuildpack{
+									{ID: "A", Version: "v1"},
+									{ID: "B", Version: "v1", Optional: true},
+								},
+								Error: "A@v1 requires dep-missing",
+							},
+							{
+								Buildpacks: []buildpack.GroupBuildpack{
+									{ID: "A", Version: "v1"},
+									{ID: "B", Version: "v1", Optional: true},
+								},
+								Pass: true,
+							},
+						},
+						Pass: true,
+					}},
+				}); s != "" {
+					t.Fatalf("Unexpected report:\n%s\n", s)
+				}
+			})
+
+			it("should record why the group failed", func() {
+				group := []buildpack.GroupBuildpack{
+					{ID: "A", Version: "v1"},
+				}
+
+				detectRuns := &sync.Map{}
+				detectRuns.Store("A@v1", buildpack.DetectRun{
+					Code: 127,
+					Err:  errors.New("some-error"),
+				})
+
+				_, _, err := resolver.Resolve(group, detectRuns)
+				if err != lifecycle.ErrBuildpack {
+					t.Fatalf("Unexpected error:\n%s\n", err)
+				}
+
+				h.AssertEq(t, len(resolver.Report.Groups), 1)
+				groupReport := resolver.Report.Groups[0]
+				h.AssertEq(t, groupReport.Pass, false)
+				h.AssertEq(t, groupReport.Error, lifecycle.ErrBuildpack.Error())
+				h.AssertEq(t, groupReport.Buildpacks[0].Result, "error")
+				h.AssertEq(t, groupReport.Buildpacks[0].ExitCode, 127)
+				h.AssertEq(t, groupReport.Buildpacks[0].Error, "some-error")
+			})
+		})
+
 		it("should fallback to alternate build plans", func() {
 			group := []buildpack.GroupBuildpack{
 				{ID: "A", Version: "v1", Optional: true, API: "0.3", Homepage: "Buildpack A Homepage"},
@@ -1,6 +1,7 @@
 package env
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -16,6 +17,50 @@ type Env struct {
 	// RootDirMap maps directories in a posix root filesystem to a slice of environment variables that
 	RootDirMap map[string][]string
 	Vars       *Vars
+
+	history []Modification
+}
+
+// Modification records a change made to an environment variable by a layer directory or an env file.
+type Modification struct {
+	// Buildpack is the name of the buildpack directory in the layers directory, and Layer is the name of the layer.
+	Buildpack string
+	Layer     string
+	// File is the env file, or the layer subdirectory that was prepended to a path list.
+	File   string
+	Name   string
+	Action ActionType
+	Old    string
+	New    string
+}
+
+func (m Modification) String() string {
+	action := string(m.Action)
+	if m.Action == ActionTypePrependPath {
+		action = "prepend-path"
+	}
+	return fmt.Sprintf("%s %s from buildpack '%s' layer '%s' (%s): '%s' -> '%s'", action, m.Name, m.Buildpack, m.Layer, m.File, m.Old, m.New)
+}
+
+// History returns the modifications made by AddRootDir and AddEnvDir, in the order they were made.
+func (p *Env) History() []Modification {
+	return p.history
+}
+
+// record sets the environment variable with the given name and records the modification.
+// layerDir is expected to be of the form <layers>/<buildpack>/<layer>.
+func (p *Env) record(layerDir, file, name string, action ActionType, v string) {
+	old := p.Vars.Get(name)
+	p.Vars.Set(name, v)
+	p.history = append(p.history, Modification{
+		Buildpack: filepath.Base(filepath.Dir(layerDir)),
+		Layer:     filepath.Base(layerDir),
+		File:      file,
+		Name:      name,
+		Action:    action,
+		Old:       old,
+		New:       v,
+	})
 }
 
 // AddRootDir modifies the environment given a root dir. If the root dir contains a directory that matches a key in
@@ -34,7 +79,7 @@ func (p *Env) AddRootDir(dir string) error {
 			return err
 		}
 		for _, key := range vars {
-			p.Vars.Set(key, childDir+prefix(p.Vars.Get(key), os.PathListSeparator))
+			p.record(absDir, childDir, key, ActionTypePrependPath, childDir+prefix(p.Vars.Get(key), os.PathListSeparator))
 		}
 	}
 	return nil
@@ -74,6 +119,10 @@ func DefaultActionType(bpAPI *api.Version) ActionType {
 // a period delimited suffix, the action matching the given suffix will be performed. If the file has no suffix,
 // the default action will be performed. If the suffix does not match a known type, AddEnvDir will ignore the file.
 func (p *Env) AddEnvDir(envDir string, defaultAction ActionType) error {
+	layerDir := filepath.Dir(envDir)
+	if filepath.Base(layerDir) == "env.launch" { // process-specific env dir
+		layerDir = filepath.Dir(layerDir)
+	}
 	if err := eachEnvFile(envDir, func(k, v string) error {
 		parts := strings.SplitN(k, ".", 2)
 		name := parts[0]
@@ -83,20 +132,21 @@ func (p *Env) AddEnvDir(envDir string, defaultAction ActionType) error {
 		} else {
 			action = defaultAction
 		}
+		file := filepath.Join(envDir, k)
 		switch action {
 		case ActionTypePrepend:
-			p.Vars.Set(name, v+prefix(p.Vars.Get(name), delim(envDir, name)...))
+			p.record(layerDir, file, name, action, v+prefix(p.Vars.Get(name), delim(envDir, name)...))
 		case ActionTypeAppend:
-			p.Vars.Set(name, suffix(p.Vars.Get(name), delim(envDir, name)...)+v)
+			p.record(layerDir, file, name, action, suffix(p.Vars.Get(name), delim(envDir, name)...)+v)
 		case ActionTypeOverride:
-			p.Vars.Set(name, v)
+			p.record(layerDir, file, name, action, v)
 		case ActionTypeDefault:
 			if p.Vars.Get(name) != "" {
 				return nil
 			}
-			p.Vars.Set(name, v)
+			p.record(layerDir, file, name, action, v)
 		case ActionTypePrependPath:
-			p.Vars.Set(name, v+prefix(p.Vars.Get(name), delim(envDir, name, os.PathListSeparator)...))
+			p.record(layerDir, file, name, action, v+prefix(p.Vars.Get(name), delim(envDir, name, os.PathListSeparator)...))
 		}
 		return nil
 	}); err != nil {
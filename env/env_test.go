@@ -211,6 +211,60 @@ func testEnv(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("#History", func() {
+		var layerDir string
+
+		it.Before(func() {
+			layerDir = filepath.Join(tmpDir, "some-buildpack", "some-layer")
+			mkdir(t, filepath.Join(layerDir, "bin"), filepath.Join(layerDir, "env.launch", "web"))
+			mkfile(t, "value-override", filepath.Join(layerDir, "env.launch", "web", "VAR.override"))
+			mkfile(t, "value-default", filepath.Join(layerDir, "env.launch", "web", "VAR.default"))
+		})
+
+		it("records each modification in order", func() {
+			envv.Vars = env.NewVars(map[string]string{"PATH": "some-path"}, false)
+			if err := envv.AddRootDir(layerDir); err != nil {
+				t.Fatalf("Error: %s\n", err)
+			}
+			if err := envv.AddEnvDir(filepath.Join(layerDir, "env.launch", "web"), env.ActionTypeOverride); err != nil {
+				t.Fatalf("Error: %s\n", err)
+			}
+
+			expected := []env.Modification{
+				{
+					Buildpack: "some-buildpack",
+					Layer:     "some-layer",
+					File:      filepath.Join(layerDir, "bin"),
+					Name:      "PATH",
+					Action:    env.ActionTypePrependPath,
+					Old:       "some-path",
+					New:       filepath.Join(layerDir, "bin") + string(os.PathListSeparator) + "some-path",
+				},
+				{
+					Buildpack: "some-buildpack",
+					Layer:     "some-layer",
+					File:      filepath.Join(layerDir, "env.launch", "web", "VAR.default"),
+					Name:      "VAR",
+					Action:    env.ActionTypeDefault,
+					Old:       "",
+					New:       "value-default",
+				},
+				{
+					Buildpack: "some-buildpack",
+					Layer:     "some-layer",
+					File:      filepath.Join(layerDir, "env.launch", "web", "VAR.override"),
+					Name:      "VAR",
+					Action:    env.ActionTypeOverride,
+					Old:       "value-default",
+					New:       "value-override",
+				},
+			}
+			if s := cmp.Diff(envv.History(), expected); s != "" {
+				t.Fatalf("Unexpected history:\n%s\n", s)
+			}
+		})
+	})
+
 	when("#Set", func() {
 		it("sets the variable", func() {
 			envv.Vars = env.NewVars(map[string]string{
@@ -0,0 +1,97 @@
+package lifecycle
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// PlanExplainer resolves an order using recorded detect outputs instead of running /bin/detect.
+type PlanExplainer struct {
+	Logger   Logger
+	Platform Platform
+	// Store is optional, and is used to expand the orders of meta-buildpacks.
+	// Buildpacks missing from the store are assumed not to be meta-buildpacks.
+	Store              BuildpackStore
+	VersionConstraints bool
+}
+
+// RecordedDetectRuns returns the detect runs recorded in a detect report, keyed by buildpack.
+func RecordedDetectRuns(bps []platform.DetectBuildpackReport) map[string]buildpack.DetectRun {
+	runs := map[string]buildpack.DetectRun{}
+	for _, bp := range bps {
+		run := buildpack.DetectRun{
+			BuildPlan: bp.BuildPlan,
+			Output:    []byte(bp.Output),
+			Code:      bp.ExitCode,
+		}
+		if bp.Error != "" {
+			run.Err = errors.New(bp.Error)
+		}
+		runs[bp.GroupBuildpack.NoOpt().NoAPI().String()] = run
+	}
+	return runs
+}
+
+// Explain resolves order as the detector would, reporting every group and plan trial.
+// Buildpacks without a recorded run are treated as failing detection.
+func (e *PlanExplainer) Explain(order buildpack.Order, runs map[string]buildpack.DetectRun) (buildpack.Group, platform.BuildPlan, *platform.DetectReport, error) {
+	detectRuns := &sync.Map{}
+	for key, run := range runs {
+		detectRuns.Store(key, run)
+	}
+	report := &platform.DetectReport{}
+	detector := &Detector{
+		DetectConfig: buildpack.DetectConfig{Logger: e.Logger},
+		Platform:     e.Platform,
+		Report:       report,
+		Resolver: &DefaultResolver{
+			Logger:             e.Logger,
+			Report:             report,
+			VersionConstraints: e.VersionConstraints,
+		},
+		Runs:  detectRuns,
+		Store: &recordedStore{store: e.Store},
+	}
+	group, plan, err := detector.Detect(order)
+	return group, plan, report, err
+}
+
+type recordedStore struct {
+	store BuildpackStore
+}
+
+func (s *recordedStore) Lookup(bpID, bpVersion string) (buildpack.Buildpack, error) {
+	if s.store != nil {
+		if bp, err := s.store.Lookup(bpID, bpVersion); err == nil {
+			return &recordedBuildpack{desc: bp.ConfigFile()}, nil
+		}
+	}
+	return &recordedBuildpack{desc: &buildpack.Descriptor{
+		Buildpack: buildpack.Info{ID: bpID, Version: bpVersion},
+	}}, nil
+}
+
+// recordedBuildpack is a buildpack whose detect output is missing from the recording.
+type recordedBuildpack struct {
+	desc *buildpack.Descriptor
+}
+
+func (b *recordedBuildpack) Build(_ buildpack.Plan, _ buildpack.BuildConfig, _ buildpack.BuildEnv) (buildpack.BuildResult, error) {
+	return buildpack.BuildResult{}, errors.New("recorded buildpacks cannot build")
+}
+
+func (b *recordedBuildpack) ConfigFile() *buildpack.Descriptor {
+	return b.desc
+}
+
+func (b *recordedBuildpack) Detect(_ *buildpack.DetectConfig, _ buildpack.BuildEnv) buildpack.DetectRun {
+	return buildpack.DetectRun{Code: CodeDetectFail, Err: errors.New("no recorded detect output")}
+}
+
+func (b *recordedBuildpack) SupportsAssetPackages() bool {
+	return false
+}
@@ -0,0 +1,115 @@
+package lifecycle_test
+
+import (
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle"
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+	"github.com/buildpacks/lifecycle/testmock"
+)
+
+func TestPlanExplainer(t *testing.T) {
+	spec.Run(t, "PlanExplainer", testPlanExplainer, spec.Report(report.Terminal{}))
+}
+
+func testPlanExplainer(t *testing.T, when spec.G, it spec.S) {
+	var (
+		mockCtrl  *gomock.Controller
+		explainer *lifecycle.PlanExplainer
+		recorded  []platform.DetectBuildpackReport
+	)
+
+	it.Before(func() {
+		mockCtrl = gomock.NewController(t)
+		platformInt := testmock.NewMockPlatform(mockCtrl)
+		platformInt.EXPECT().SupportsAssetPackages().Return(true).AnyTimes()
+		explainer = &lifecycle.PlanExplainer{
+			Logger:   &log.Logger{Handler: memory.New()},
+			Platform: platformInt,
+		}
+		recorded = []platform.DetectBuildpackReport{
+			{
+				GroupBuildpack: buildpack.GroupBuildpack{ID: "A", Version: "v1", Optional: true},
+				BuildPlan: buildpack.BuildPlan{PlanSections: buildpack.PlanSections{
+					Requires: []buildpack.Require{{Name: "missing"}},
+				}},
+			},
+			{
+				GroupBuildpack: buildpack.GroupBuildpack{ID: "B", Version: "v1", API: "0.5"},
+				BuildPlan: buildpack.BuildPlan{PlanSections: buildpack.PlanSections{
+					Provides: []buildpack.Provide{{Name: "dep1"}},
+					Requires: []buildpack.Require{{Name: "dep1"}},
+				}},
+			},
+			{
+				GroupBuildpack: buildpack.GroupBuildpack{ID: "C", Version: "v1"},
+				ExitCode:       100,
+			},
+		}
+	})
+
+	it.After(func() {
+		mockCtrl.Finish()
+	})
+
+	it("resolves the order using recorded detect outputs", func() {
+		order := buildpack.Order{
+			{Group: []buildpack.GroupBuildpack{{ID: "C", Version: "v1"}}},
+			{Group: []buildpack.GroupBuildpack{{ID: "A", Version: "v1", Optional: true}, {ID: "B", Version: "v1"}}},
+		}
+
+		group, plan, detectReport, err := explainer.Explain(order, lifecycle.RecordedDetectRuns(recorded))
+		h.AssertNil(t, err)
+		h.AssertEq(t, group, buildpack.Group{Group: []buildpack.GroupBuildpack{{ID: "B", Version: "v1"}}})
+		h.AssertEq(t, len(plan.Entries), 1)
+		h.AssertEq(t, plan.Entries[0].Requires[0].Name, "dep1")
+
+		h.AssertEq(t, len(detectReport.Groups), 2)
+		h.AssertEq(t, detectReport.Groups[0].Pass, false)
+		h.AssertEq(t, detectReport.Groups[0].Buildpacks[0].Result, "fail")
+		h.AssertEq(t, detectReport.Groups[1].Pass, true)
+		h.AssertEq(t, detectReport.Groups[1].Trials[0].Skipped, []string{"A@v1 requires missing"})
+	})
+
+	it("treats buildpacks without recorded output as failing detection", func() {
+		order := buildpack.Order{
+			{Group: []buildpack.GroupBuildpack{{ID: "D", Version: "v1"}}},
+		}
+
+		_, _, detectReport, err := explainer.Explain(order, lifecycle.RecordedDetectRuns(recorded))
+		if err, ok := err.(*buildpack.Error); !ok || err.Type != buildpack.ErrTypeFailedDetection {
+			t.Fatalf("Unexpected error:\n%s\n", err)
+		}
+		h.AssertEq(t, detectReport.Groups[0].Buildpacks[0].Error, "no recorded detect output")
+	})
+
+	when("a buildpack store is provided", func() {
+		it("expands meta-buildpacks", func() {
+			store := testmock.NewMockBuildpackStore(mockCtrl)
+			meta := testmock.NewMockBuildpack(mockCtrl)
+			meta.EXPECT().ConfigFile().Return(&buildpack.Descriptor{
+				Buildpack: buildpack.Info{ID: "M", Version: "v1"},
+				Order:     buildpack.Order{{Group: []buildpack.GroupBuildpack{{ID: "B", Version: "v1"}}}},
+			}).AnyTimes()
+			store.EXPECT().Lookup("M", "v1").Return(meta, nil)
+			store.EXPECT().Lookup("B", "v1").Return(nil, errors.New("not found"))
+			explainer.Store = store
+
+			group, _, _, err := explainer.Explain(
+				buildpack.Order{{Group: []buildpack.GroupBuildpack{{ID: "M", Version: "v1"}}}},
+				lifecycle.RecordedDetectRuns(recorded),
+			)
+			h.AssertNil(t, err)
+			h.AssertEq(t, group, buildpack.Group{Group: []buildpack.GroupBuildpack{{ID: "B", Version: "v1"}}})
+		})
+	})
+}
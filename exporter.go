@@ -7,10 +7,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/buildpacks/imgutil"
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/buildpack"
@@ -18,6 +20,7 @@ import (
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/layers"
 	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/sbom"
 )
 
 type Cache interface {
@@ -33,9 +36,21 @@ type Cache interface {
 
 type Exporter struct {
 	Buildpacks   []buildpack.GroupBuildpack
+	DetectCache  []platform.DetectCacheEntry // optional, stored in the cache metadata
 	LayerFactory LayerFactory
 	Logger       Logger
 	PlatformAPI  *api.Version
+	SBOM         bool // exports SPDX and CycloneDX documents converted from the BOM when set
+}
+
+// launchCacheImage is implemented by images that may reuse layers from the launch cache instead of the previous image.
+type launchCacheImage interface {
+	ReusedFromCache(diffID string) bool
+}
+
+// createdTimeImage is implemented by images whose created time may be set instead of imgutil.NormalizedDateTime.
+type createdTimeImage interface {
+	SetCreatedTime(t time.Time) error
 }
 
 //go:generate mockgen -package testmock -destination testmock/layer_factory.go github.com/buildpacks/lifecycle LayerFactory
@@ -62,6 +77,8 @@ type ExportOptions struct {
 	Stack              platform.StackMetadata
 	Project            platform.ProjectMetadata
 	DefaultProcessType string
+	CreatedTime        time.Time         // when set, the image created time, also added as the org.opencontainers.image.created label
+	OCILabels          map[string]string // org.opencontainers.image labels that take precedence over the values found by the exporter and buildpack-provided labels
 }
 
 func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
@@ -77,6 +94,7 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 		return platform.ExportReport{}, errors.Wrapf(err, "app dir absolute path")
 	}
 
+	var layerReports []platform.LayerReport // layers added to the image, in order
 	meta := platform.LayersMetadata{}
 	meta.RunImage.TopLayer, err = opts.WorkingImage.TopLayer()
 	if err != nil {
@@ -86,23 +104,33 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 	meta.RunImage.Reference = opts.RunImageRef
 	meta.Stack = opts.Stack
 
+	if err := e.setCreatedTime(opts); err != nil {
+		return platform.ExportReport{}, err
+	}
+
 	buildMD := &platform.BuildMetadata{}
 	if _, err := toml.DecodeFile(launch.GetMetadataFilePath(opts.LayersDir), buildMD); err != nil {
 		return platform.ExportReport{}, errors.Wrap(err, "read build metadata")
 	}
 
 	// buildpack-provided layers
-	if err := e.addBuildpackLayers(opts, &meta); err != nil {
+	if err := e.addBuildpackLayers(opts, &meta, &layerReports); err != nil {
 		return platform.ExportReport{}, err
 	}
 
 	// app layers (split into 1 or more slices)
-	if err := e.addAppLayers(opts, buildMD.Slices, &meta); err != nil {
+	if err := e.addAppLayers(opts, buildMD.Slices, &meta, &layerReports); err != nil {
 		return platform.ExportReport{}, errors.Wrap(err, "exporting app layers")
 	}
 
+	if e.SBOM {
+		if err := e.addSBOMLayer(opts, buildMD, &meta, &layerReports); err != nil {
+			return platform.ExportReport{}, err
+		}
+	}
+
 	// launcher layers (launcher binary, launcher config, process symlinks)
-	if err := e.addLauncherLayers(opts, buildMD, &meta); err != nil {
+	if err := e.addLauncherLayers(opts, buildMD, &meta, &layerReports); err != nil {
 		return platform.ExportReport{}, err
 	}
 
@@ -136,7 +164,7 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 	}
 
 	report := platform.ExportReport{}
-	report.Build, err = e.makeBuildReport(opts.LayersDir)
+	report.Build, err = e.makeBuildReport(opts.LayersDir, buildMD.UnmetRequires)
 	if err != nil {
 		return platform.ExportReport{}, err
 	}
@@ -144,6 +172,7 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 	if err != nil {
 		return platform.ExportReport{}, err
 	}
+	report.Image.Layers = layerReports
 	if !e.supportsManifestSize() {
 		// unset manifest size in report.toml for old platform API versions
 		report.Image.ManifestSize = 0
@@ -152,12 +181,29 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 	return report, nil
 }
 
-func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *platform.LayersMetadata) error {
-	for _, bp := range e.Buildpacks {
+func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *platform.LayersMetadata, reports *[]platform.LayerReport) error {
+	bpDirs := make([]bpLayersDir, len(e.Buildpacks))
+	var withContents []bpLayer
+	for i, bp := range e.Buildpacks {
 		bpDir, err := readBuildpackLayersDir(opts.LayersDir, bp, e.Logger)
 		if err != nil {
 			return errors.Wrapf(err, "reading layers for buildpack '%s'", bp.ID)
 		}
+		bpDirs[i] = bpDir
+		for _, fsLayer := range bpDir.findLayers(forLaunch) {
+			if fsLayer.hasLocalContents() {
+				withContents = append(withContents, fsLayer)
+			}
+		}
+	}
+	created, err := e.createDirLayers(withContents)
+	if err != nil {
+		return err
+	}
+
+	// layers are added to the image in order, so that the image and its metadata do not depend on which layer was created first
+	for i, bp := range e.Buildpacks {
+		bpDir := bpDirs[i]
 		bpMD := platform.BuildpackLayersMetadata{
 			ID:      bp.ID,
 			Version: bp.Version,
@@ -171,13 +217,9 @@ func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *platform.LayersM
 				return errors.Wrapf(err, "reading '%s' metadata", fsLayer.Identifier())
 			}
 
-			if fsLayer.hasLocalContents() {
-				layer, err := e.LayerFactory.DirLayer(fsLayer.Identifier(), fsLayer.path)
-				if err != nil {
-					return errors.Wrapf(err, "creating layer")
-				}
+			if layer, ok := created[fsLayer.Identifier()]; ok {
 				origLayerMetadata := opts.OrigMetadata.MetadataForBuildpack(bp.ID).Layers[fsLayer.name()]
-				lmd.SHA, err = e.addOrReuseLayer(opts.WorkingImage, layer, origLayerMetadata.SHA)
+				lmd.SHA, err = e.addOrReuseLayer(opts.WorkingImage, layer, origLayerMetadata.SHA, platform.LayerReport{Buildpack: bp.ID, Layer: fsLayer.name()}, reports)
 				if err != nil {
 					return err
 				}
@@ -190,11 +232,18 @@ func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *platform.LayersM
 					return fmt.Errorf("cannot reuse '%s', previous image has no metadata for layer '%s'", fsLayer.Identifier(), fsLayer.Identifier())
 				}
 
-				e.Logger.Infof("Reusing layer '%s'\n", fsLayer.Identifier())
-				e.Logger.Debugf("Layer '%s' SHA: %s\n", fsLayer.Identifier(), origLayerMetadata.SHA)
+				logger := withAttribution(e.Logger, "layer", fsLayer.Identifier())
+				logger.Infof("Reusing layer '%s'\n", fsLayer.Identifier())
+				logger.Debugf("Layer '%s' SHA: %s\n", fsLayer.Identifier(), origLayerMetadata.SHA)
 				if err := opts.WorkingImage.ReuseLayer(origLayerMetadata.SHA); err != nil {
 					return errors.Wrapf(err, "reusing layer: '%s'", fsLayer.Identifier())
 				}
+				*reports = append(*reports, layerReport(opts.WorkingImage, platform.LayerReport{
+					ID:        fsLayer.Identifier(),
+					Buildpack: bp.ID,
+					Layer:     fsLayer.name(),
+					DiffID:    origLayerMetadata.SHA,
+				}, "", true))
 				lmd.SHA = origLayerMetadata.SHA
 			}
 			bpMD.Layers[fsLayer.name()] = lmd
@@ -212,12 +261,67 @@ func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *platform.LayersM
 	return nil
 }
 
-func (e *Exporter) addLauncherLayers(opts ExportOptions, buildMD *platform.BuildMetadata, meta *platform.LayersMetadata) error {
+// createDirLayers creates a layer for each of fsLayers concurrently, and returns them by identifier.
+// The layer factory limits how many layers are written at a time.
+func (e *Exporter) createDirLayers(fsLayers []bpLayer) (map[string]layers.Layer, error) {
+	created := make([]layers.Layer, len(fsLayers))
+	var g errgroup.Group
+	for i, fsLayer := range fsLayers {
+		i, fsLayer := i, fsLayer
+		g.Go(func() error {
+			var err error
+			created[i], err = e.LayerFactory.DirLayer(fsLayer.Identifier(), fsLayer.path)
+			return errors.Wrapf(err, "creating layer")
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	out := make(map[string]layers.Layer, len(created))
+	for i, fsLayer := range fsLayers {
+		out[fsLayer.Identifier()] = created[i]
+	}
+	return out, nil
+}
+
+// SBOMDir is the directory in <layers> where the exporter writes SBOM documents.
+// Buildpack layers directories are named by escaped buildpack IDs, which only contain '_' in place of '/',
+// and a buildpack ID cannot begin with '/', so no buildpack layers directory has this name.
+const SBOMDir = "_sbom"
+
+// addSBOMLayer writes SBOM documents converted from the BOM to <layers>/_sbom, where the platform may collect them,
+// and exports them in a layer.
+func (e *Exporter) addSBOMLayer(opts ExportOptions, buildMD *platform.BuildMetadata, meta *platform.LayersMetadata, reports *[]platform.LayerReport) error {
+	sbomDir := filepath.Join(opts.LayersDir, SBOMDir)
+	created := imgutil.NormalizedDateTime
+	if !opts.CreatedTime.IsZero() {
+		created = opts.CreatedTime
+	}
+	if err := sbom.Write(sbomDir, buildMD.BOM, opts.WorkingImage.Name(), opts.LauncherConfig.Metadata.Version, created); err != nil {
+		return errors.Wrap(err, "writing SBOM")
+	}
+	sbomLayer, err := e.LayerFactory.DirLayer("sbom", sbomDir)
+	if err != nil {
+		return errors.Wrap(err, "creating layer 'sbom'")
+	}
+	var origSHA string
+	if opts.OrigMetadata.SBOM != nil {
+		origSHA = opts.OrigMetadata.SBOM.SHA
+	}
+	sha, err := e.addOrReuseLayer(opts.WorkingImage, sbomLayer, origSHA, platform.LayerReport{}, reports)
+	if err != nil {
+		return errors.Wrap(err, "exporting SBOM layer")
+	}
+	meta.SBOM = &platform.LayerMetadata{SHA: sha}
+	return nil
+}
+
+func (e *Exporter) addLauncherLayers(opts ExportOptions, buildMD *platform.BuildMetadata, meta *platform.LayersMetadata, reports *[]platform.LayerReport) error {
 	launcherLayer, err := e.LayerFactory.LauncherLayer(opts.LauncherConfig.Path)
 	if err != nil {
 		return errors.Wrap(err, "creating launcher layers")
 	}
-	meta.Launcher.SHA, err = e.addOrReuseLayer(opts.WorkingImage, launcherLayer, opts.OrigMetadata.Launcher.SHA)
+	meta.Launcher.SHA, err = e.addOrReuseLayer(opts.WorkingImage, launcherLayer, opts.OrigMetadata.Launcher.SHA, platform.LayerReport{}, reports)
 	if err != nil {
 		return errors.Wrap(err, "exporting launcher configLayer")
 	}
@@ -225,18 +329,18 @@ func (e *Exporter) addLauncherLayers(opts ExportOptions, buildMD *platform.Build
 	if err != nil {
 		return errors.Wrapf(err, "creating layer '%s'", configLayer.ID)
 	}
-	meta.Config.SHA, err = e.addOrReuseLayer(opts.WorkingImage, configLayer, opts.OrigMetadata.Config.SHA)
+	meta.Config.SHA, err = e.addOrReuseLayer(opts.WorkingImage, configLayer, opts.OrigMetadata.Config.SHA, platform.LayerReport{}, reports)
 	if err != nil {
 		return errors.Wrap(err, "exporting config layer")
 	}
 
-	if err := e.launcherConfig(opts, buildMD, meta); err != nil {
+	if err := e.launcherConfig(opts, buildMD, meta, reports); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (e *Exporter) addAppLayers(opts ExportOptions, slices []layers.Slice, meta *platform.LayersMetadata) error {
+func (e *Exporter) addAppLayers(opts ExportOptions, slices []layers.Slice, meta *platform.LayersMetadata, reports *[]platform.LayerReport) error {
 	// creating app layers (slices + app dir)
 	sliceLayers, err := e.LayerFactory.SliceLayers(opts.AppDir, slices)
 	if err != nil {
@@ -244,7 +348,7 @@ func (e *Exporter) addAppLayers(opts ExportOptions, slices []layers.Slice, meta
 	}
 
 	var numberOfReusedLayers int
-	for _, slice := range sliceLayers {
+	for i, slice := range sliceLayers {
 		var err error
 
 		found := false
@@ -263,6 +367,7 @@ func (e *Exporter) addAppLayers(opts ExportOptions, slices []layers.Slice, meta
 		if err != nil {
 			return err
 		}
+		*reports = append(*reports, layerReport(opts.WorkingImage, platform.LayerReport{ID: slice.ID, Slice: i + 1, DiffID: slice.Digest}, slice.TarPath, found))
 		e.Logger.Debugf("Layer '%s' SHA: %s\n", slice.ID, slice.Digest)
 		meta.App = append(meta.App, platform.LayerMetadata{SHA: slice.Digest})
 	}
@@ -309,15 +414,46 @@ func (e *Exporter) setLabels(opts ExportOptions, meta platform.LayersMetadata, b
 		return errors.Wrap(err, "set project metadata label")
 	}
 
+	ociLabels := e.ociLabels(opts)
+	for _, key := range sortedKeys(ociLabels) {
+		e.Logger.Infof("Adding label '%s'", key)
+		if err := opts.WorkingImage.SetLabel(key, ociLabels[key]); err != nil {
+			return errors.Wrapf(err, "set label '%s'", key)
+		}
+	}
+
 	for _, label := range buildMD.Labels {
 		e.Logger.Infof("Adding label '%s'", label.Key)
 		if err := opts.WorkingImage.SetLabel(label.Key, label.Value); err != nil {
 			return errors.Wrapf(err, "set buildpack-provided label '%s'", label.Key)
 		}
 	}
+
+	// the labels provided by the platform take precedence over those provided by buildpacks
+	for _, key := range sortedKeys(opts.OCILabels) {
+		e.Logger.Infof("Adding label '%s'", key)
+		if err := opts.WorkingImage.SetLabel(key, opts.OCILabels[key]); err != nil {
+			return errors.Wrapf(err, "set label '%s'", key)
+		}
+	}
 	return nil
 }
 
+// setCreatedTime sets the image created time when one is given and the image supports it.
+// Other images are saved with imgutil.NormalizedDateTime.
+func (e *Exporter) setCreatedTime(opts ExportOptions) error {
+	if opts.CreatedTime.IsZero() {
+		return nil
+	}
+	img, ok := opts.WorkingImage.(createdTimeImage)
+	if !ok {
+		e.Logger.Warnf("Image '%s' does not support setting the created time, it will be created at '%s'", opts.WorkingImage.Name(), imgutil.NormalizedDateTime.Format(time.RFC3339))
+		return nil
+	}
+	e.Logger.Debugf("Setting created time: '%s'", opts.CreatedTime.Format(time.RFC3339))
+	return errors.Wrap(img.SetCreatedTime(opts.CreatedTime), "set image created time")
+}
+
 func (e *Exporter) setEnv(opts ExportOptions, launchMD launch.Metadata) error {
 	e.Logger.Debugf("Setting %s=%s", cmd.EnvLayersDir, opts.LayersDir)
 	if err := opts.WorkingImage.SetEnv(cmd.EnvLayersDir, opts.LayersDir); err != nil {
@@ -397,7 +533,7 @@ func (e *Exporter) entrypoint(launchMD launch.Metadata, userDefaultProcessType,
 }
 
 // processTypes adds
-func (e *Exporter) launcherConfig(opts ExportOptions, buildMD *platform.BuildMetadata, meta *platform.LayersMetadata) error {
+func (e *Exporter) launcherConfig(opts ExportOptions, buildMD *platform.BuildMetadata, meta *platform.LayersMetadata, reports *[]platform.LayerReport) error {
 	if e.supportsMulticallLauncher() {
 		launchMD := launch.Metadata{
 			Processes: buildMD.Processes,
@@ -407,7 +543,7 @@ func (e *Exporter) launcherConfig(opts ExportOptions, buildMD *platform.BuildMet
 			if err != nil {
 				return errors.Wrapf(err, "creating layer '%s'", processTypesLayer.ID)
 			}
-			meta.ProcessTypes.SHA, err = e.addOrReuseLayer(opts.WorkingImage, processTypesLayer, opts.OrigMetadata.ProcessTypes.SHA)
+			meta.ProcessTypes.SHA, err = e.addOrReuseLayer(opts.WorkingImage, processTypesLayer, opts.OrigMetadata.ProcessTypes.SHA, platform.LayerReport{}, reports)
 			if err != nil {
 				return errors.Wrapf(err, "exporting layer '%s'", processTypesLayer.ID)
 			}
@@ -436,22 +572,52 @@ func processTypeWarning(launchMD launch.Metadata, defaultProcessType string) str
 	return fmt.Sprintf("default process type '%s' not present in list %+v", defaultProcessType, typeList)
 }
 
-func (e *Exporter) addOrReuseLayer(image imgutil.Image, layer layers.Layer, previousSHA string) (string, error) {
+// addOrReuseLayer adds the layer to the image, or reuses it from the previous image when its SHA is previousSHA,
+// and appends it to reports.
+func (e *Exporter) addOrReuseLayer(image imgutil.Image, layer layers.Layer, previousSHA string, report platform.LayerReport, reports *[]platform.LayerReport) (string, error) {
 	layer, err := e.LayerFactory.DirLayer(layer.ID, layer.TarPath)
 	if err != nil {
 		return "", errors.Wrapf(err, "creating layer '%s'", layer.ID)
 	}
+	report.ID = layer.ID
+	report.DiffID = layer.Digest
+	logger := withAttribution(e.Logger, "layer", layer.ID)
 	if layer.Digest == previousSHA {
-		e.Logger.Infof("Reusing layer '%s'\n", layer.ID)
-		e.Logger.Debugf("Layer '%s' SHA: %s\n", layer.ID, layer.Digest)
-		return layer.Digest, image.ReuseLayer(previousSHA)
+		logger.Infof("Reusing layer '%s'\n", layer.ID)
+		logger.Debugf("Layer '%s' SHA: %s\n", layer.ID, layer.Digest)
+		if err := image.ReuseLayer(previousSHA); err != nil {
+			return "", err
+		}
+		*reports = append(*reports, layerReport(image, report, layer.TarPath, true))
+		return layer.Digest, nil
+	}
+	logger.Infof("Adding layer '%s'\n", layer.ID)
+	logger.Debugf("Layer '%s' SHA: %s\n", layer.ID, layer.Digest)
+	if err := image.AddLayerWithDiffID(layer.TarPath, layer.Digest); err != nil {
+		return "", err
+	}
+	*reports = append(*reports, layerReport(image, report, layer.TarPath, false))
+	return layer.Digest, nil
+}
+
+// layerReport completes the report of a layer added to image, with its uncompressed size when the tarball at tarPath is available.
+func layerReport(image imgutil.Image, report platform.LayerReport, tarPath string, reused bool) platform.LayerReport {
+	report.Status = platform.LayerStatusAdded
+	if reused {
+		report.Status = platform.LayerStatusReused
+		if cacheImage, ok := image.(launchCacheImage); ok && cacheImage.ReusedFromCache(report.DiffID) {
+			report.Status = platform.LayerStatusLaunchCache
+		}
+	}
+	if tarPath != "" {
+		if fi, err := os.Stat(tarPath); err == nil {
+			report.Size = fi.Size()
+		}
 	}
-	e.Logger.Infof("Adding layer '%s'\n", layer.ID)
-	e.Logger.Debugf("Layer '%s' SHA: %s\n", layer.ID, layer.Digest)
-	return layer.Digest, image.AddLayerWithDiffID(layer.TarPath, layer.Digest)
+	return report
 }
 
-func (e *Exporter) makeBuildReport(layersDir string) (platform.BuildReport, error) {
+func (e *Exporter) makeBuildReport(layersDir string, unmet []platform.UnmetRequire) (platform.BuildReport, error) {
 	if e.PlatformAPI.Compare(api.MustParse("0.5")) < 0 { // platform API < 0.5
 		return platform.BuildReport{}, nil
 	}
@@ -467,5 +633,5 @@ func (e *Exporter) makeBuildReport(layersDir string) (platform.BuildReport, erro
 		}
 		out = append(out, buildpack.WithBuildpack(bp, bpBuildReport.BOM)...)
 	}
-	return platform.BuildReport{BOM: out}, nil
+	return platform.BuildReport{BOM: out, UnmetRequires: unmet}, nil
 }
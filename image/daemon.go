@@ -0,0 +1,134 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/buildpacks/imgutil/local"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+)
+
+// ReadDaemonImage returns the image with the name or ID ref in the daemon, or nil if it does not exist.
+// The image is saved to a tarball in dir, so that its layers may be read more than once.
+func ReadDaemonImage(docker client.CommonAPIClient, ref, dir string) (v1.Image, error) {
+	ctx := context.Background()
+	if _, _, err := docker.ImageInspectWithRaw(ctx, ref); err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "inspecting image %q", ref)
+	}
+	rc, err := docker.ImageSave(ctx, []string{ref})
+	if err != nil {
+		return nil, errors.Wrapf(err, "saving image %q", ref)
+	}
+	defer rc.Close()
+	f, err := ioutil.TempFile(dir, "daemon-image.*.tar")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, rc); err != nil {
+		return nil, errors.Wrapf(err, "saving image %q", ref)
+	}
+	return tarball.ImageFromPath(f.Name(), nil)
+}
+
+// DaemonImage is an image that is loaded into a docker daemon.
+// It is used instead of imgutil's local image when the created time of the image is set.
+type DaemonImage struct {
+	*v1Image
+	docker client.CommonAPIClient
+}
+
+// NewDaemonImage returns an image named repoName based on base, which is loaded into docker.
+// Layers of prevImage, when it is not nil, may be reused.
+func NewDaemonImage(repoName string, docker client.CommonAPIClient, base, prevImage v1.Image) (*DaemonImage, error) {
+	img, err := newV1Image(repoName, base, prevImage)
+	if err != nil {
+		return nil, err
+	}
+	return &DaemonImage{v1Image: img, docker: docker}, nil
+}
+
+// Save loads the image into the daemon, tagged with the name of the image and each of additionalNames.
+func (i *DaemonImage) Save(additionalNames ...string) error {
+	if err := i.normalize(); err != nil {
+		return err
+	}
+	var diagnostics []imgutil.SaveDiagnostic
+	refToImage := map[name.Reference]v1.Image{}
+	for _, n := range append([]string{i.repoName}, additionalNames...) {
+		tag, err := name.NewTag(n, name.WeakValidation)
+		if err != nil {
+			diagnostics = append(diagnostics, imgutil.SaveDiagnostic{ImageName: n, Cause: err})
+			continue
+		}
+		refToImage[tag] = i.image
+	}
+	if len(refToImage) > 0 {
+		if err := i.load(refToImage); err != nil {
+			for ref := range refToImage {
+				diagnostics = append(diagnostics, imgutil.SaveDiagnostic{ImageName: ref.Name(), Cause: err})
+			}
+		}
+	}
+	if len(diagnostics) > 0 {
+		return imgutil.SaveError{Errors: diagnostics}
+	}
+	return nil
+}
+
+// load streams a docker-archive of the images to the daemon, as `docker load` does.
+func (i *DaemonImage) load(refToImage map[name.Reference]v1.Image) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(tarball.MultiRefWrite(refToImage, pw))
+	}()
+	res, err := i.docker.ImageLoad(context.Background(), pr, true)
+	if err != nil {
+		pr.CloseWithError(err)
+		return errors.Wrap(err, "loading image")
+	}
+	defer res.Body.Close()
+	decoder := json.NewDecoder(res.Body)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return errors.Wrap(err, "parsing daemon response")
+		}
+		if msg.Error != nil {
+			return errors.Wrap(msg.Error, "loading image")
+		}
+	}
+}
+
+// Delete removes the image with the name of the image from the daemon.
+func (i *DaemonImage) Delete() error {
+	_, err := i.docker.ImageRemove(context.Background(), i.repoName, types.ImageRemoveOptions{
+		Force:         true,
+		PruneChildren: true,
+	})
+	return err
+}
+
+// Identifier returns the ID of the image, which is the digest of its config.
+func (i *DaemonImage) Identifier() (imgutil.Identifier, error) {
+	id, err := i.image.ConfigName()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting image ID")
+	}
+	return local.IDIdentifier{ImageID: strings.TrimPrefix(id.String(), "sha256:")}, nil
+}
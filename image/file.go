@@ -0,0 +1,154 @@
+package image
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/match"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+)
+
+// OutputFormat is the format of a file that an image is written to.
+type OutputFormat string
+
+const (
+	OutputOCI           OutputFormat = "oci"            // an OCI image layout directory
+	OutputDockerArchive OutputFormat = "docker-archive" // a tarball that can be loaded with `docker load`
+)
+
+// annotationRefName is the annotation that holds the name of an image in the index of an OCI image layout.
+const annotationRefName = "org.opencontainers.image.ref.name"
+
+// Output is a file that an image is written to instead of a registry or a daemon.
+type Output struct {
+	Format OutputFormat
+	Path   string
+}
+
+// ParseOutput parses an output of the form <format>:<path>, e.g. oci:/path/to/layout.
+func ParseOutput(s string) (Output, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return Output{}, fmt.Errorf("output '%s' must have the form '<format>:<path>'", s)
+	}
+	switch format := OutputFormat(parts[0]); format {
+	case OutputOCI, OutputDockerArchive:
+		return Output{Format: format, Path: parts[1]}, nil
+	default:
+		return Output{}, fmt.Errorf("unknown output format '%s', must be one of '%s' or '%s'", parts[0], OutputOCI, OutputDockerArchive)
+	}
+}
+
+func (o Output) String() string {
+	return fmt.Sprintf("%s:%s", o.Format, o.Path)
+}
+
+// ReadLayoutImage returns the image named repoName in the OCI image layout at path,
+// or nil if the layout or the image does not exist.
+// A directory without an index.json, e.g. an empty volume, is not yet a layout.
+func ReadLayoutImage(path, repoName string) (v1.Image, error) {
+	if _, err := os.Stat(filepath.Join(path, "index.json")); os.IsNotExist(err) {
+		return nil, nil
+	}
+	index, err := layout.ImageIndexFromPath(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading image layout '%s'", path)
+	}
+	manifest, err := index.IndexManifest()
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading index of image layout '%s'", path)
+	}
+	for _, desc := range manifest.Manifests {
+		if desc.Annotations[annotationRefName] == repoName {
+			return index.Image(desc.Digest)
+		}
+	}
+	return nil, nil
+}
+
+// FileImage is an image that is saved to an OCI image layout or a docker-archive tarball.
+type FileImage struct {
+	*v1Image
+	output Output
+}
+
+// NewFileImage returns an image named repoName based on base, which is saved to output.
+// Layers of prevImage, when it is not nil, may be reused.
+func NewFileImage(repoName string, output Output, base, prevImage v1.Image) (*FileImage, error) {
+	img, err := newV1Image(repoName, base, prevImage)
+	if err != nil {
+		return nil, err
+	}
+	return &FileImage{v1Image: img, output: output}, nil
+}
+
+// Save writes the image to the output with the name of the image and each of additionalNames.
+// In an OCI image layout, images that previously had one of the names are replaced.
+func (i *FileImage) Save(additionalNames ...string) error {
+	if err := i.normalize(); err != nil {
+		return err
+	}
+	names := append([]string{i.repoName}, additionalNames...)
+	switch i.output.Format {
+	case OutputOCI:
+		return i.saveLayout(names)
+	case OutputDockerArchive:
+		return i.saveArchive(names)
+	default:
+		return fmt.Errorf("unknown output format '%s'", i.output.Format)
+	}
+}
+
+func (i *FileImage) saveLayout(names []string) error {
+	path, err := layout.FromPath(i.output.Path)
+	if err != nil {
+		if path, err = layout.Write(i.output.Path, empty.Index); err != nil {
+			return errors.Wrapf(err, "creating image layout '%s'", i.output.Path)
+		}
+	}
+	var diagnostics []imgutil.SaveDiagnostic
+	for _, n := range names {
+		if err := path.ReplaceImage(i.image, match.Name(n), layout.WithAnnotations(map[string]string{annotationRefName: n})); err != nil {
+			diagnostics = append(diagnostics, imgutil.SaveDiagnostic{ImageName: n, Cause: err})
+		}
+	}
+	if len(diagnostics) > 0 {
+		return imgutil.SaveError{Errors: diagnostics}
+	}
+	return nil
+}
+
+func (i *FileImage) saveArchive(names []string) error {
+	var diagnostics []imgutil.SaveDiagnostic
+	refToImage := map[name.Reference]v1.Image{}
+	for _, n := range names {
+		tag, err := name.NewTag(n, name.WeakValidation)
+		if err != nil {
+			diagnostics = append(diagnostics, imgutil.SaveDiagnostic{ImageName: n, Cause: err})
+			continue
+		}
+		refToImage[tag] = i.image
+	}
+	if len(refToImage) > 0 {
+		if err := tarball.MultiRefWriteToFile(i.output.Path, refToImage); err != nil {
+			return errors.Wrapf(err, "writing docker archive '%s'", i.output.Path)
+		}
+	}
+	if len(diagnostics) > 0 {
+		return imgutil.SaveError{Errors: diagnostics}
+	}
+	return nil
+}
+
+// Delete is not supported, images written to files are deleted by removing the files.
+func (i *FileImage) Delete() error {
+	return errors.New("deleting an image written to a file is not supported")
+}
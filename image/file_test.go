@@ -0,0 +1,218 @@
+package image_test
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/imgutil"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/image"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestFileImage(t *testing.T) {
+	spec.Run(t, "FileImage", testFileImage, spec.Report(report.Terminal{}))
+}
+
+func testFileImage(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir    string
+		base      v1.Image
+		layerPath string
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.file-image")
+		h.AssertNil(t, err)
+		base, err = random.Image(64, 1)
+		h.AssertNil(t, err)
+
+		layerPath = filepath.Join(tmpDir, "layer.tar")
+		r, err := h.CreateSingleFileTar("/some-file", "some-contents")
+		h.AssertNil(t, err)
+		f, err := os.Create(layerPath)
+		h.AssertNil(t, err)
+		_, err = io.Copy(f, r)
+		h.AssertNil(t, err)
+		h.AssertNil(t, f.Close())
+	})
+
+	it.After(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	when("#ParseOutput", func() {
+		it("parses the format and path", func() {
+			output, err := image.ParseOutput("oci:/some/path")
+			h.AssertNil(t, err)
+			h.AssertEq(t, output, image.Output{Format: image.OutputOCI, Path: "/some/path"})
+		})
+
+		it("fails for unknown formats", func() {
+			_, err := image.ParseOutput("some-format:/some/path")
+			h.AssertError(t, err, "unknown output format 'some-format'")
+		})
+
+		it("fails without a path", func() {
+			_, err := image.ParseOutput("oci")
+			h.AssertError(t, err, "must have the form '<format>:<path>'")
+		})
+	})
+
+	when("the output is an OCI image layout", func() {
+		var output image.Output
+
+		it.Before(func() {
+			output = image.Output{Format: image.OutputOCI, Path: filepath.Join(tmpDir, "layout")}
+		})
+
+		it("writes the image with each name", func() {
+			img, err := image.NewFileImage("some/app:latest", output, base, nil)
+			h.AssertNil(t, err)
+			h.AssertNil(t, img.SetLabel("some-label", "some-value"))
+			h.AssertNil(t, img.AddLayer(layerPath))
+			h.AssertNil(t, img.Save("some/app:other"))
+
+			for _, n := range []string{"some/app:latest", "some/app:other"} {
+				saved, err := image.ReadLayoutImage(output.Path, n)
+				h.AssertNil(t, err)
+				cfg, err := saved.ConfigFile()
+				h.AssertNil(t, err)
+				h.AssertEq(t, cfg.Config.Labels["some-label"], "some-value")
+				layers, err := saved.Layers()
+				h.AssertNil(t, err)
+				h.AssertEq(t, len(layers), 2)
+			}
+
+			id, err := img.Identifier()
+			h.AssertNil(t, err)
+			saved, err := image.ReadLayoutImage(output.Path, "some/app:latest")
+			h.AssertNil(t, err)
+			digest, err := saved.Digest()
+			h.AssertNil(t, err)
+			h.AssertEq(t, id.String(), "index.docker.io/some/app@"+digest.String())
+		})
+
+		it("saves the image with the normalized created time", func() {
+			img, err := image.NewFileImage("some/app", output, base, nil)
+			h.AssertNil(t, err)
+			h.AssertNil(t, img.Save())
+
+			saved, err := image.ReadLayoutImage(output.Path, "some/app")
+			h.AssertNil(t, err)
+			cfg, err := saved.ConfigFile()
+			h.AssertNil(t, err)
+			h.AssertEq(t, cfg.Created.Time.Equal(imgutil.NormalizedDateTime), true)
+		})
+
+		when("#SetCreatedTime", func() {
+			it("saves the image with the created time", func() {
+				created := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+				img, err := image.NewFileImage("some/app", output, base, nil)
+				h.AssertNil(t, err)
+				h.AssertNil(t, img.SetCreatedTime(created))
+				h.AssertNil(t, img.AddLayer(layerPath))
+				h.AssertNil(t, img.Save())
+
+				saved, err := image.ReadLayoutImage(output.Path, "some/app")
+				h.AssertNil(t, err)
+				cfg, err := saved.ConfigFile()
+				h.AssertNil(t, err)
+				h.AssertEq(t, cfg.Created.Time.Equal(created), true)
+				for _, history := range cfg.History {
+					h.AssertEq(t, history.Created.Time.Equal(created), true)
+				}
+			})
+		})
+
+		it("reuses layers from the previous image and replaces it", func() {
+			img, err := image.NewFileImage("some/app", output, base, nil)
+			h.AssertNil(t, err)
+			h.AssertNil(t, img.AddLayer(layerPath))
+			h.AssertNil(t, img.Save())
+			topLayer, err := img.TopLayer()
+			h.AssertNil(t, err)
+
+			prevImage, err := image.ReadLayoutImage(output.Path, "some/app")
+			h.AssertNil(t, err)
+			img, err = image.NewFileImage("some/app", output, base, prevImage)
+			h.AssertNil(t, err)
+			h.AssertEq(t, img.Found(), true)
+			h.AssertNil(t, img.ReuseLayer(topLayer))
+			h.AssertNil(t, img.SetLabel("some-label", "some-new-value"))
+			h.AssertNil(t, img.Save())
+
+			index, err := layout.ImageIndexFromPath(output.Path)
+			h.AssertNil(t, err)
+			manifest, err := index.IndexManifest()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(manifest.Manifests), 1)
+
+			saved, err := image.ReadLayoutImage(output.Path, "some/app")
+			h.AssertNil(t, err)
+			cfg, err := saved.ConfigFile()
+			h.AssertNil(t, err)
+			h.AssertEq(t, cfg.Config.Labels["some-label"], "some-new-value")
+			h.AssertEq(t, cfg.RootFS.DiffIDs[1].String(), topLayer)
+		})
+
+		it("returns no previous image when the layout does not exist", func() {
+			prevImage, err := image.ReadLayoutImage(output.Path, "some/app")
+			h.AssertNil(t, err)
+			h.AssertEq(t, prevImage == nil, true)
+		})
+
+		when("the layout path is an empty directory", func() {
+			it.Before(func() {
+				h.AssertNil(t, os.Mkdir(output.Path, 0755))
+			})
+
+			it("returns no previous image", func() {
+				prevImage, err := image.ReadLayoutImage(output.Path, "some/app")
+				h.AssertNil(t, err)
+				h.AssertEq(t, prevImage == nil, true)
+			})
+
+			it("writes the layout", func() {
+				img, err := image.NewFileImage("some/app", output, base, nil)
+				h.AssertNil(t, err)
+				h.AssertNil(t, img.Save())
+
+				saved, err := image.ReadLayoutImage(output.Path, "some/app")
+				h.AssertNil(t, err)
+				h.AssertEq(t, saved == nil, false)
+			})
+		})
+	})
+
+	when("the output is a docker-archive", func() {
+		it("writes a tarball that can be loaded", func() {
+			output := image.Output{Format: image.OutputDockerArchive, Path: filepath.Join(tmpDir, "image.tar")}
+			img, err := image.NewFileImage("some/app:latest", output, base, nil)
+			h.AssertNil(t, err)
+			h.AssertNil(t, img.SetEnv("SOME_VAR", "some-value"))
+			h.AssertNil(t, img.AddLayer(layerPath))
+			h.AssertNil(t, img.Save())
+
+			loaded, err := tarball.ImageFromPath(output.Path, nil)
+			h.AssertNil(t, err)
+			cfg, err := loaded.ConfigFile()
+			h.AssertNil(t, err)
+			h.AssertContains(t, cfg.Config.Env, "SOME_VAR=some-value")
+			layers, err := loaded.Layers()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(layers), 2)
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package image
+
+import (
+	"github.com/buildpacks/imgutil"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+)
+
+// RegistryImage is an image that is pushed to a registry.
+// It is used instead of imgutil's remote image when the created time of the image is set.
+type RegistryImage struct {
+	*v1Image
+	keychain authn.Keychain
+}
+
+// NewRegistryImage returns an image named repoName based on base, which is pushed with credentials from keychain.
+// Layers of prevImage, when it is not nil, may be reused.
+func NewRegistryImage(repoName string, keychain authn.Keychain, base, prevImage v1.Image) (*RegistryImage, error) {
+	img, err := newV1Image(repoName, base, prevImage)
+	if err != nil {
+		return nil, err
+	}
+	return &RegistryImage{v1Image: img, keychain: keychain}, nil
+}
+
+// Save pushes the image with the name of the image and each of additionalNames.
+func (i *RegistryImage) Save(additionalNames ...string) error {
+	if err := i.normalize(); err != nil {
+		return err
+	}
+	var diagnostics []imgutil.SaveDiagnostic
+	for _, n := range append([]string{i.repoName}, additionalNames...) {
+		ref, err := name.ParseReference(n, name.WeakValidation)
+		if err == nil {
+			err = remote.Write(ref, i.image, remote.WithAuthFromKeychain(i.keychain))
+		}
+		if err != nil {
+			diagnostics = append(diagnostics, imgutil.SaveDiagnostic{ImageName: n, Cause: err})
+		}
+	}
+	if len(diagnostics) > 0 {
+		return imgutil.SaveError{Errors: diagnostics}
+	}
+	return nil
+}
+
+// Delete deletes the manifest of the image from the registry.
+func (i *RegistryImage) Delete() error {
+	id, err := i.Identifier()
+	if err != nil {
+		return err
+	}
+	ref, err := name.ParseReference(id.String(), name.WeakValidation)
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(remote.Delete(ref, remote.WithAuthFromKeychain(i.keychain)), "deleting image %q", i.repoName)
+}
@@ -0,0 +1,136 @@
+package image_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/image"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestRegistryImage(t *testing.T) {
+	spec.Run(t, "RegistryImage", testRegistryImage, spec.Report(report.Terminal{}))
+}
+
+func testRegistryImage(t *testing.T, when spec.G, it spec.S) {
+	var (
+		server    *httptest.Server
+		repoName  string
+		tmpDir    string
+		base      v1.Image
+		layerPath string
+	)
+
+	it.Before(func() {
+		server = httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+		u, err := url.Parse(server.URL)
+		h.AssertNil(t, err)
+		repoName = u.Host + "/some/app"
+
+		tmpDir, err = ioutil.TempDir("", "lifecycle.registry-image")
+		h.AssertNil(t, err)
+		base, err = random.Image(64, 1)
+		h.AssertNil(t, err)
+		layerPath, _, _ = h.RandomLayer(t, tmpDir)
+	})
+
+	it.After(func() {
+		server.Close()
+		os.RemoveAll(tmpDir)
+	})
+
+	pulled := func(n string) v1.Image {
+		ref, err := name.ParseReference(n)
+		h.AssertNil(t, err)
+		img, err := remote.Image(ref)
+		h.AssertNil(t, err)
+		return img
+	}
+
+	it("pushes the image with each name", func() {
+		img, err := image.NewRegistryImage(repoName, authn.DefaultKeychain, base, nil)
+		h.AssertNil(t, err)
+		h.AssertNil(t, img.AddLayer(layerPath))
+		h.AssertNil(t, img.Save(repoName+":other-tag"))
+
+		id, err := img.Identifier()
+		h.AssertNil(t, err)
+		for _, n := range []string{repoName, repoName + ":other-tag"} {
+			digest, err := pulled(n).Digest()
+			h.AssertNil(t, err)
+			h.AssertEq(t, id.String(), repoName+"@"+digest.String())
+		}
+	})
+
+	it("pushes the image with the normalized created time", func() {
+		img, err := image.NewRegistryImage(repoName, authn.DefaultKeychain, base, nil)
+		h.AssertNil(t, err)
+		h.AssertNil(t, img.Save())
+
+		cfg, err := pulled(repoName).ConfigFile()
+		h.AssertNil(t, err)
+		h.AssertEq(t, cfg.Created.Time.Equal(imgutil.NormalizedDateTime), true)
+	})
+
+	when("#SetCreatedTime", func() {
+		it("pushes the image with the created time", func() {
+			created := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+			img, err := image.NewRegistryImage(repoName, authn.DefaultKeychain, base, nil)
+			h.AssertNil(t, err)
+			h.AssertNil(t, img.SetCreatedTime(created))
+			h.AssertNil(t, img.AddLayer(layerPath))
+			h.AssertNil(t, img.Save())
+
+			cfg, err := pulled(repoName).ConfigFile()
+			h.AssertNil(t, err)
+			h.AssertEq(t, cfg.Created.Time.Equal(created), true)
+			for _, history := range cfg.History {
+				h.AssertEq(t, history.Created.Time.Equal(created), true)
+			}
+		})
+	})
+
+	it("reuses layers from the previous image", func() {
+		prev, err := image.NewRegistryImage(repoName, authn.DefaultKeychain, base, nil)
+		h.AssertNil(t, err)
+		h.AssertNil(t, prev.AddLayer(layerPath))
+		h.AssertNil(t, prev.Save())
+		topLayer, err := prev.TopLayer()
+		h.AssertNil(t, err)
+
+		img, err := image.NewRegistryImage(repoName, authn.DefaultKeychain, base, pulled(repoName))
+		h.AssertNil(t, err)
+		h.AssertEq(t, img.Found(), true)
+		h.AssertNil(t, img.ReuseLayer(topLayer))
+		h.AssertNil(t, img.Save())
+
+		reusedTop, err := img.TopLayer()
+		h.AssertNil(t, err)
+		h.AssertEq(t, reusedTop, topLayer)
+	})
+
+	it("fails for names that cannot be parsed", func() {
+		img, err := image.NewRegistryImage(repoName, authn.DefaultKeychain, base, nil)
+		h.AssertNil(t, err)
+		err = img.Save("some/INVALID")
+		saveErr, ok := err.(imgutil.SaveError)
+		h.AssertEq(t, ok, true)
+		h.AssertEq(t, len(saveErr.Errors), 1)
+		h.AssertEq(t, saveErr.Errors[0].ImageName, "some/INVALID")
+	})
+}
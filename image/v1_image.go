@@ -0,0 +1,333 @@
+package image
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/buildpacks/imgutil/remote"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/pkg/errors"
+)
+
+// v1Image implements imgutil.Image, except for saving and deleting, on an image that is held in memory.
+// Unlike imgutil's images, its created time may be set.
+type v1Image struct {
+	repoName   string
+	image      v1.Image
+	prevImage  v1.Image
+	prevLayers []v1.Layer
+	created    time.Time
+}
+
+// newV1Image returns an image named repoName based on base.
+// Layers of prevImage, when it is not nil, may be reused.
+func newV1Image(repoName string, base, prevImage v1.Image) (*v1Image, error) {
+	if base == nil {
+		base = empty.Image
+	}
+	i := &v1Image{
+		repoName:  repoName,
+		image:     base,
+		prevImage: prevImage,
+	}
+	if prevImage != nil {
+		var err error
+		if i.prevLayers, err = prevImage.Layers(); err != nil {
+			return nil, errors.Wrap(err, "getting previous image layers")
+		}
+	}
+	return i, nil
+}
+
+// SetCreatedTime sets the created time of the saved image, which is imgutil.NormalizedDateTime by default.
+func (i *v1Image) SetCreatedTime(t time.Time) error {
+	i.created = t
+	return nil
+}
+
+func (i *v1Image) Name() string {
+	return i.repoName
+}
+
+func (i *v1Image) Rename(name string) {
+	i.repoName = name
+}
+
+// Found returns true if there is a previous image with the same name.
+func (i *v1Image) Found() bool {
+	return i.prevImage != nil
+}
+
+func (i *v1Image) Label(key string) (string, error) {
+	labels, err := i.Labels()
+	if err != nil {
+		return "", err
+	}
+	return labels[key], nil
+}
+
+func (i *v1Image) Labels() (map[string]string, error) {
+	cfg, err := i.configFile()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Config.Labels, nil
+}
+
+func (i *v1Image) Env(key string) (string, error) {
+	cfg, err := i.configFile()
+	if err != nil {
+		return "", err
+	}
+	for _, envVar := range cfg.Config.Env {
+		parts := strings.SplitN(envVar, "=", 2)
+		if parts[0] == key && len(parts) == 2 {
+			return parts[1], nil
+		}
+	}
+	return "", nil
+}
+
+func (i *v1Image) Entrypoint() ([]string, error) {
+	cfg, err := i.configFile()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Config.Entrypoint, nil
+}
+
+func (i *v1Image) OS() (string, error) {
+	cfg, err := i.configFile()
+	if err != nil {
+		return "", err
+	}
+	return cfg.OS, nil
+}
+
+func (i *v1Image) OSVersion() (string, error) {
+	cfg, err := i.configFile()
+	if err != nil {
+		return "", err
+	}
+	return cfg.OSVersion, nil
+}
+
+func (i *v1Image) Architecture() (string, error) {
+	cfg, err := i.configFile()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Architecture, nil
+}
+
+func (i *v1Image) CreatedAt() (time.Time, error) {
+	cfg, err := i.configFile()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cfg.Created.UTC(), nil
+}
+
+func (i *v1Image) SetLabel(key, val string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		if cfg.Config.Labels == nil {
+			cfg.Config.Labels = map[string]string{}
+		}
+		cfg.Config.Labels[key] = val
+	})
+}
+
+func (i *v1Image) RemoveLabel(key string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		delete(cfg.Config.Labels, key)
+	})
+}
+
+func (i *v1Image) SetEnv(key, val string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		ignoreCase := cfg.OS == "windows"
+		for idx, e := range cfg.Config.Env {
+			foundKey := strings.SplitN(e, "=", 2)[0]
+			if foundKey == key || (ignoreCase && strings.EqualFold(foundKey, key)) {
+				cfg.Config.Env[idx] = fmt.Sprintf("%s=%s", key, val)
+				return
+			}
+		}
+		cfg.Config.Env = append(cfg.Config.Env, fmt.Sprintf("%s=%s", key, val))
+	})
+}
+
+func (i *v1Image) SetWorkingDir(dir string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		cfg.Config.WorkingDir = dir
+	})
+}
+
+func (i *v1Image) SetEntrypoint(ep ...string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		cfg.Config.Entrypoint = ep
+	})
+}
+
+func (i *v1Image) SetCmd(cmd ...string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		cfg.Config.Cmd = cmd
+	})
+}
+
+func (i *v1Image) SetOS(osVal string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		cfg.OS = osVal
+	})
+}
+
+func (i *v1Image) SetOSVersion(osVersion string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		cfg.OSVersion = osVersion
+	})
+}
+
+func (i *v1Image) SetArchitecture(architecture string) error {
+	return i.mutateConfig(func(cfg *v1.ConfigFile) {
+		cfg.Architecture = architecture
+	})
+}
+
+// Rebase is not supported, the image is rebased by exporting it again.
+func (i *v1Image) Rebase(string, imgutil.Image) error {
+	return fmt.Errorf("rebasing image %q is not supported", i.repoName)
+}
+
+func (i *v1Image) TopLayer() (string, error) {
+	layers, err := i.image.Layers()
+	if err != nil {
+		return "", err
+	}
+	if len(layers) == 0 {
+		return "", fmt.Errorf("image %q has no layers", i.repoName)
+	}
+	diffID, err := layers[len(layers)-1].DiffID()
+	if err != nil {
+		return "", err
+	}
+	return diffID.String(), nil
+}
+
+func (i *v1Image) GetLayer(diffID string) (io.ReadCloser, error) {
+	layers, err := i.image.Layers()
+	if err != nil {
+		return nil, err
+	}
+	layer, err := findLayer(layers, diffID)
+	if err != nil {
+		return nil, err
+	}
+	return layer.Uncompressed()
+}
+
+func (i *v1Image) AddLayer(path string) error {
+	layer, err := tarball.LayerFromFile(path)
+	if err != nil {
+		return err
+	}
+	i.image, err = mutate.AppendLayers(i.image, layer)
+	return errors.Wrap(err, "add layer")
+}
+
+func (i *v1Image) AddLayerWithDiffID(path, _ string) error {
+	return i.AddLayer(path)
+}
+
+func (i *v1Image) ReuseLayer(diffID string) error {
+	layer, err := findLayer(i.prevLayers, diffID)
+	if err != nil {
+		return errors.Wrap(err, "previous image")
+	}
+	i.image, err = mutate.AppendLayers(i.image, layer)
+	return err
+}
+
+// normalize zeroes the creation time and history of the image, as imgutil does when it saves an image.
+// When a created time was set, it is used instead.
+func (i *v1Image) normalize() error {
+	created := imgutil.NormalizedDateTime
+	if !i.created.IsZero() {
+		created = i.created
+	}
+	var err error
+	i.image, err = mutate.CreatedAt(i.image, v1.Time{Time: created})
+	if err != nil {
+		return errors.Wrap(err, "set creation time")
+	}
+	layers, err := i.image.Layers()
+	if err != nil {
+		return errors.Wrap(err, "get image layers")
+	}
+	return errors.Wrap(i.mutateConfig(func(cfg *v1.ConfigFile) {
+		cfg.History = make([]v1.History, len(layers))
+		for idx := range cfg.History {
+			cfg.History[idx] = v1.History{Created: v1.Time{Time: created}}
+		}
+		cfg.DockerVersion = ""
+		cfg.Container = ""
+	}), "zeroing history")
+}
+
+// Identifier returns the digest of the image, qualified by the repository of its name.
+func (i *v1Image) Identifier() (imgutil.Identifier, error) {
+	ref, err := name.ParseReference(i.repoName, name.WeakValidation)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing image name '%s'", i.repoName)
+	}
+	digest, err := i.image.Digest()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting image digest")
+	}
+	return remote.DigestIdentifier{Digest: ref.Context().Digest(digest.String())}, nil
+}
+
+func (i *v1Image) ManifestSize() (int64, error) {
+	return i.image.Size()
+}
+
+func (i *v1Image) configFile() (*v1.ConfigFile, error) {
+	cfg, err := i.image.ConfigFile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting config file for image %q", i.repoName)
+	}
+	if cfg == nil {
+		return nil, fmt.Errorf("missing config for image %q", i.repoName)
+	}
+	return cfg, nil
+}
+
+func (i *v1Image) mutateConfig(fn func(cfg *v1.ConfigFile)) error {
+	cfg, err := i.configFile()
+	if err != nil {
+		return err
+	}
+	cfg = cfg.DeepCopy()
+	fn(cfg)
+	i.image, err = mutate.ConfigFile(i.image, cfg)
+	return err
+}
+
+func findLayer(layers []v1.Layer, diffID string) (v1.Layer, error) {
+	for _, layer := range layers {
+		dID, err := layer.DiffID()
+		if err != nil {
+			return nil, errors.Wrap(err, "get diff ID for layer")
+		}
+		if diffID == dID.String() {
+			return layer, nil
+		}
+	}
+	return nil, fmt.Errorf("image did not have layer with diff id %q", diffID)
+}
@@ -1,6 +1,8 @@
 package launch
 
 import (
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -42,6 +44,7 @@ type Env interface {
 	AddEnvDir(envDir string, defaultAction env.ActionType) error
 	AddRootDir(baseDir string) error
 	Get(string) string
+	History() []env.Modification
 	List() []string
 	Set(name, k string)
 }
@@ -75,6 +78,25 @@ func (l *Launcher) LaunchProcess(self string, proc Process) error {
 	return l.launchWithShell(self, proc)
 }
 
+// ExplainEnv uses cmd to select a process like Launch, but instead of launching it, applies the environment
+// modifications from the buildpack layers and writes each of them to w, in the order they were made.
+// Executables in exec.d directories are not run.
+func (l *Launcher) ExplainEnv(w io.Writer, cmd []string) error {
+	proc, err := l.ProcessFor(cmd)
+	if err != nil {
+		return errors.Wrap(err, "determine start command")
+	}
+	if err := l.doEnv(proc.Type); err != nil {
+		return errors.Wrap(err, "modify env")
+	}
+	for _, m := range l.Env.History() {
+		if _, err := fmt.Fprintln(w, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (l *Launcher) launchDirect(proc Process) error {
 	if err := l.Setenv("PATH", l.Env.Get("PATH")); err != nil {
 		return errors.Wrap(err, "set path")
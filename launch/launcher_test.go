@@ -1,6 +1,7 @@
 package launch_test
 
 import (
+	"bytes"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -15,6 +16,7 @@ import (
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
+	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/env"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/launch/testmock"
@@ -678,6 +680,40 @@ func testLauncher(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 	})
+
+	when("ExplainEnv", func() {
+		it.Before(func() {
+			launcher.PlatformAPI = api.Platform.Latest()
+			launcher.Processes = []launch.Process{{Type: "web", Command: "some-command"}}
+			mkdir(t, filepath.Join(tmpDir, "launch", "0.5_buildpack", "layer1", "env.launch", "web"))
+		})
+
+		it("writes the env modifications for the process without launching it", func() {
+			layerDir := filepath.Join(tmpDir, "launch", "0.5_buildpack", "layer1")
+			gomock.InOrder(
+				mockEnv.EXPECT().AddRootDir(layerDir),
+				mockEnv.EXPECT().AddEnvDir(filepath.Join(layerDir, "env"), env.ActionTypeOverride),
+				mockEnv.EXPECT().AddEnvDir(filepath.Join(layerDir, "env.launch"), env.ActionTypeOverride),
+				mockEnv.EXPECT().AddEnvDir(filepath.Join(layerDir, "env.launch", "web"), env.ActionTypeOverride),
+				mockEnv.EXPECT().History().Return([]env.Modification{{
+					Buildpack: "0.5_buildpack",
+					Layer:     "layer1",
+					File:      filepath.Join(layerDir, "env.launch", "web", "JAVA_OPTS"),
+					Name:      "JAVA_OPTS",
+					Action:    env.ActionTypeOverride,
+					Old:       "-Xmx1g",
+					New:       "-Xmx2g",
+				}}),
+			)
+
+			out := &bytes.Buffer{}
+			h.AssertNil(t, launcher.ExplainEnv(out, nil))
+
+			h.AssertEq(t, out.String(), "override JAVA_OPTS from buildpack '0.5_buildpack' layer 'layer1' ("+
+				filepath.Join(layerDir, "env.launch", "web", "JAVA_OPTS")+"): '-Xmx1g' -> '-Xmx2g'\n")
+			h.AssertEq(t, len(syscallExecArgsColl), 0)
+		})
+	})
 }
 
 func mkfile(t *testing.T, data string, paths ...string) {
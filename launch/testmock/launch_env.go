@@ -77,6 +77,20 @@ func (mr *MockEnvMockRecorder) Get(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockEnv)(nil).Get), arg0)
 }
 
+// History mocks base method.
+func (m *MockEnv) History() []env.Modification {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History")
+	ret0, _ := ret[0].([]env.Modification)
+	return ret0
+}
+
+// History indicates an expected call of History.
+func (mr *MockEnvMockRecorder) History() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockEnv)(nil).History))
+}
+
 // List mocks base method.
 func (m *MockEnv) List() []string {
 	m.ctrl.T.Helper()
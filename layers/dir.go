@@ -8,7 +8,7 @@ import (
 
 // DirLayer creates a layer from the given directory
 // DirLayer will set the UID and GID of entries describing dir and its children (but not its parents)
-//    to Factory.UID and Factory.GID
+//    to Factory.UID and Factory.GID, and their ModTime to Factory.ModTime when it is set
 func (f *Factory) DirLayer(id string, dir string) (layer Layer, err error) {
 	dir, err = filepath.Abs(dir)
 	if err != nil {
@@ -24,6 +24,7 @@ func (f *Factory) DirLayer(id string, dir string) (layer Layer, err error) {
 		}
 		tw.WithUID(f.UID)
 		tw.WithGID(f.GID)
+		f.withModTime(tw)
 		return archive.AddDirToArchive(tw, dir)
 	})
 }
@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -141,6 +142,37 @@ func testDirs(t *testing.T, when spec.G, it spec.S) {
 				fmt.Sprintf("Reusing tarball for layer \"some-layer-id\" with SHA: %s\n", dirLayer.Digest),
 			)
 		})
+
+		when("ModTime is set", func() {
+			it("sets the mod time of the directory and its children", func() {
+				factory.ArtifactsDir = filepath.Join(factory.ArtifactsDir, "mod-time")
+				h.AssertNil(t, os.Mkdir(factory.ArtifactsDir, 0755))
+				factory.ModTime = time.Unix(1600000000, 0).UTC()
+				layer, err := factory.DirLayer("some-layer-id", dir)
+				h.AssertNil(t, err)
+
+				lf, err := os.Open(layer.TarPath)
+				h.AssertNil(t, err)
+				defer lf.Close()
+				tr := tar.NewReader(lf)
+				var found int
+				for {
+					header, err := tr.Next()
+					if err == io.EOF {
+						break
+					}
+					h.AssertNil(t, err)
+					if !strings.HasPrefix(header.Name, tarPath(dir)) {
+						continue // only dir and its children are given ModTime
+					}
+					found++
+					if !header.ModTime.Equal(factory.ModTime) {
+						t.Fatalf("expected entry '%s' to have mod time '%s', got '%s'", header.Name, factory.ModTime, header.ModTime)
+					}
+				}
+				h.AssertEq(t, found > 0, true)
+			})
+		})
 	})
 }
 
@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/buildpacks/lifecycle/archive"
 )
@@ -12,8 +14,14 @@ type Factory struct {
 	ArtifactsDir string // ArtifactsDir is the directory where layer files are written
 	UID, GID     int    // UID and GID are used to normalize layer entries
 	Logger       Logger
+	Parallelism  int       // Parallelism is the maximum number of layers written concurrently, unlimited when <= 0
+	ModTime      time.Time // ModTime is the modification time of entries in directory and slice layers, archive.NormalizedModTime when zero
 
+	mu        sync.Mutex
 	tarHashes map[string]string // tarHases Stores hashes of layer tarballs for reuse between the export and cache steps.
+
+	slotsOnce sync.Once
+	slots     chan struct{} // slots limits the number of layers written concurrently to Parallelism
 }
 
 type Layer struct {
@@ -38,10 +46,7 @@ type Logger interface {
 
 func (f *Factory) writeLayer(id string, addEntries func(tw *archive.NormalizingTarWriter) error) (layer Layer, err error) {
 	tarPath := filepath.Join(f.ArtifactsDir, escape(id)+".tar")
-	if f.tarHashes == nil {
-		f.tarHashes = make(map[string]string)
-	}
-	if sha, ok := f.tarHashes[tarPath]; ok {
+	if sha, ok := f.tarHash(tarPath); ok {
 		f.Logger.Debugf("Reusing tarball for layer %q with SHA: %s\n", id, sha)
 		return Layer{
 			ID:      id,
@@ -49,6 +54,7 @@ func (f *Factory) writeLayer(id string, addEntries func(tw *archive.NormalizingT
 			Digest:  sha,
 		}, nil
 	}
+	defer f.acquireSlot()()
 	lw, err := newFileLayerWriter(tarPath)
 	if err != nil {
 		return Layer{}, err
@@ -67,7 +73,7 @@ func (f *Factory) writeLayer(id string, addEntries func(tw *archive.NormalizingT
 		return Layer{}, err
 	}
 	digest := lw.Digest()
-	f.tarHashes[tarPath] = digest
+	f.setTarHash(tarPath, digest)
 	return Layer{
 		ID:      id,
 		Digest:  digest,
@@ -75,6 +81,37 @@ func (f *Factory) writeLayer(id string, addEntries func(tw *archive.NormalizingT
 	}, err
 }
 
+// tarHash and setTarHash guard tarHashes, because layers may be written concurrently.
+func (f *Factory) tarHash(tarPath string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sha, ok := f.tarHashes[tarPath]
+	return sha, ok
+}
+
+func (f *Factory) setTarHash(tarPath, sha string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tarHashes == nil {
+		f.tarHashes = make(map[string]string)
+	}
+	f.tarHashes[tarPath] = sha
+}
+
+// acquireSlot waits until fewer than f.Parallelism layers are being written, and returns a function that releases the slot.
+func (f *Factory) acquireSlot() (release func()) {
+	f.slotsOnce.Do(func() {
+		if f.Parallelism > 0 {
+			f.slots = make(chan struct{}, f.Parallelism)
+		}
+	})
+	if f.slots == nil {
+		return func() {}
+	}
+	f.slots <- struct{}{}
+	return func() { <-f.slots }
+}
+
 func escape(id string) string {
 	return strings.Replace(id, "/", "_", -1)
 }
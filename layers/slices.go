@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/buildpacks/lifecycle/archive"
 )
@@ -22,8 +23,8 @@ type Slice struct {
 // * The first n layers will contain files matched by the any Path in the nth Slice
 // * The final layer will contain any files in dir that were not included in a previous layer
 // Some layers may be empty
+// The layers are written concurrently, up to Factory.Parallelism at a time.
 func (f *Factory) SliceLayers(dir string, slices []Slice) ([]Layer, error) {
-	var sliceLayers []Layer
 	dir, err := filepath.Abs(dir)
 	if err != nil {
 		return nil, err
@@ -33,35 +34,44 @@ func (f *Factory) SliceLayers(dir string, slices []Slice) ([]Layer, error) {
 		return nil, err
 	}
 
-	//add one layer per slice
-	for i, slice := range slices {
-		layerID := fmt.Sprintf("slice-%d", i+1)
-		layer, err := f.createLayerFromSlice(slice, sdir, layerID)
+	// the files of each layer are selected in order, because files in a slice are excluded from the following layers
+	var layerFiles [][]archive.PathInfo
+	for _, slice := range slices {
+		files, err := sliceMatches(slice, sdir)
 		if err != nil {
 			return nil, err
 		}
-		sliceLayers = append(sliceLayers, layer)
+		layerFiles = append(layerFiles, files)
 	}
-
 	//add remaining files in a single layer
-	layerID := fmt.Sprintf("slice-%d", len(slices)+1)
-	finalLayer, err := f.createLayerFromFiles(layerID, sdir, sdir.remainingFiles())
-	if err != nil {
+	layerFiles = append(layerFiles, sdir.remainingFiles())
+
+	sliceLayers := make([]Layer, len(layerFiles))
+	var g errgroup.Group
+	for i := range layerFiles {
+		i := i
+		g.Go(func() error {
+			var err error
+			sliceLayers[i], err = f.createLayerFromFiles(fmt.Sprintf("slice-%d", i+1), sdir, layerFiles[i])
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
 		return nil, err
 	}
-	return append(sliceLayers, finalLayer), nil
+	return sliceLayers, nil
 }
 
-func (f *Factory) createLayerFromSlice(slice Slice, sdir *sliceableDir, layerID string) (Layer, error) {
+func sliceMatches(slice Slice, sdir *sliceableDir) ([]archive.PathInfo, error) {
 	var matches []string
 	for _, path := range slice.Paths {
 		globMatches, err := glob(sdir, path)
 		if err != nil {
-			return Layer{}, err
+			return nil, err
 		}
 		matches = append(matches, globMatches...)
 	}
-	return f.createLayerFromFiles(layerID, sdir, sdir.sliceFiles(matches))
+	return sdir.sliceFiles(matches), nil
 }
 
 func glob(sdir *sliceableDir, pattern string) ([]string, error) {
@@ -103,6 +113,7 @@ func (f *Factory) createLayerFromFiles(layerID string, sdir *sliceableDir, files
 			}
 			tw.WithUID(f.UID)
 			tw.WithGID(f.GID)
+			f.withModTime(tw)
 			return archive.AddFilesToArchive(tw, files)
 		}
 		return nil
@@ -216,6 +216,27 @@ func testSlices(t *testing.T, when spec.G, it spec.S) {
 				h.AssertEq(t, sliceLayers[4].ID, "slice-5")
 			})
 
+			it("creates the same layers with limited parallelism", func() {
+				artifactDir, err := ioutil.TempDir("", "layers.slices.layer")
+				h.AssertNil(t, err)
+				defer os.RemoveAll(artifactDir)
+				sequential := &layers.Factory{ArtifactsDir: artifactDir, UID: factory.UID, GID: factory.GID, Parallelism: 1}
+
+				slices := []layers.Slice{
+					{Paths: []string{"*.txt", filepath.Join("**", "*.txt")}},
+					{Paths: []string{"other-dir"}},
+					{Paths: []string{filepath.Join("dir-link", "*")}},
+					{Paths: []string{filepath.Join("..", "**", "dir-to-exclude")}},
+				}
+				sequentialLayers, err := sequential.SliceLayers(dirToSlice, slices)
+				h.AssertNil(t, err)
+				h.AssertEq(t, len(sequentialLayers), len(sliceLayers))
+				for i := range sliceLayers {
+					h.AssertEq(t, sequentialLayers[i].ID, sliceLayers[i].ID)
+					h.AssertEq(t, sequentialLayers[i].Digest, sliceLayers[i].Digest)
+				}
+			})
+
 			it("creates slice from pattern", func() {
 				assertTarEntries(t, sliceLayers[0].TarPath, append(parents(t, dirToSlice), []*tar.Header{
 					{
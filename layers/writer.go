@@ -44,3 +44,10 @@ func tarWriter(lw *layerWriter) *archive.NormalizingTarWriter {
 	tw.WithModTime(archive.NormalizedModTime)
 	return tw
 }
+
+// withModTime overrides the normalized ModTime of subsequently written entries with Factory.ModTime when it is set
+func (f *Factory) withModTime(tw *archive.NormalizingTarWriter) {
+	if !f.ModTime.IsZero() {
+		tw.WithModTime(f.ModTime)
+	}
+}
@@ -1,5 +1,9 @@
 package lifecycle
 
+import (
+	"io"
+)
+
 type Logger interface {
 	Debug(msg string)
 	Debugf(fmt string, v ...interface{})
@@ -13,3 +17,33 @@ type Logger interface {
 	Error(msg string)
 	Errorf(fmt string, v ...interface{})
 }
+
+// attributedLogger is implemented by loggers that can attribute their records to a buildpack or layer.
+type attributedLogger interface {
+	WithAttribution(key, value string) Logger
+}
+
+// withAttribution returns a logger that attributes its records to the buildpack or layer given by key and value,
+// if logger supports attribution.
+func withAttribution(logger Logger, key, value string) Logger {
+	if l, ok := logger.(attributedLogger); ok {
+		return l.WithAttribution(key, value)
+	}
+	return logger
+}
+
+// attributedWriter is implemented by writers that can attribute the output written to them to a buildpack.
+// The returned writer must be closed to flush any remaining output.
+type attributedWriter interface {
+	WithAttribution(key, value string) io.WriteCloser
+}
+
+// writerWithAttribution returns a writer that attributes its output to the buildpack given by key and value,
+// if w supports attribution, and a function that flushes the returned writer.
+func writerWithAttribution(w io.Writer, key, value string) (io.Writer, func()) {
+	if aw, ok := w.(attributedWriter); ok {
+		wc := aw.WithAttribution(key, value)
+		return wc, func() { _ = wc.Close() }
+	}
+	return w, func() {}
+}
@@ -0,0 +1,213 @@
+package lifecycle
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// ociLabels returns the org.opencontainers.image labels derived for the app image.
+// Values are taken from the project metadata, then from the git repository in the app dir.
+// The created label is only derived for images whose created time is set.
+func (e *Exporter) ociLabels(opts ExportOptions) map[string]string {
+	labels := map[string]string{}
+	if src := opts.Project.Source; src != nil && src.Type == "git" {
+		setIfString(labels, platform.OCISourceLabel, src.Metadata["repository"])
+		setIfString(labels, platform.OCIRevisionLabel, src.Version["commit"])
+	}
+
+	git, err := readGitDir(filepath.Join(opts.AppDir, ".git"))
+	if err != nil && !os.IsNotExist(err) {
+		e.Logger.Debugf("Not reading git metadata from app dir: %s", err)
+	}
+	setIfMissing(labels, platform.OCISourceLabel, git.source)
+	setIfMissing(labels, platform.OCIRevisionLabel, git.revision)
+	setIfMissing(labels, platform.OCIVersionLabel, git.version)
+
+	if _, ok := opts.WorkingImage.(createdTimeImage); ok && !opts.CreatedTime.IsZero() {
+		labels[platform.OCICreatedLabel] = opts.CreatedTime.UTC().Format(time.RFC3339)
+	}
+	return labels
+}
+
+func setIfString(labels map[string]string, key string, v interface{}) {
+	if s, ok := v.(string); ok && s != "" {
+		labels[key] = s
+	}
+}
+
+func setIfMissing(labels map[string]string, key, v string) {
+	if _, ok := labels[key]; !ok && v != "" {
+		labels[key] = v
+	}
+}
+
+// gitMetadata is read from the .git directory without a git executable.
+// version is only found for a tag pointing directly at the checked out commit.
+type gitMetadata struct {
+	source   string // the URL of the 'origin' remote, without credentials
+	revision string // the checked out commit
+	version  string // a tag of the checked out commit
+}
+
+var gitSHA = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+func readGitDir(gitDir string) (gitMetadata, error) {
+	fi, err := os.Stat(gitDir)
+	if err != nil {
+		return gitMetadata{}, err
+	}
+	if !fi.IsDir() {
+		// worktrees and submodules have a .git file pointing to the git dir
+		contents, err := ioutil.ReadFile(gitDir)
+		if err != nil {
+			return gitMetadata{}, err
+		}
+		linked := strings.TrimSpace(strings.TrimPrefix(string(contents), "gitdir:"))
+		if !filepath.IsAbs(linked) {
+			linked = filepath.Join(filepath.Dir(gitDir), linked)
+		}
+		gitDir = linked
+	}
+
+	var md gitMetadata
+	md.source = originURL(filepath.Join(gitDir, "config"))
+	packed := packedRefs(filepath.Join(gitDir, "packed-refs"))
+
+	head, err := ioutil.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return md, err
+	}
+	md.revision = strings.TrimSpace(string(head))
+	if ref := strings.TrimPrefix(md.revision, "ref: "); ref != md.revision {
+		md.revision = resolveRef(gitDir, ref, packed)
+	}
+	if !gitSHA.MatchString(md.revision) {
+		md.revision = ""
+		return md, nil
+	}
+
+	md.version = tagFor(gitDir, md.revision, packed)
+	return md, nil
+}
+
+// packedRefs returns the commits of packed refs, using the peeled commit of annotated tags
+func packedRefs(path string) map[string]string {
+	refs := map[string]string{}
+	f, err := os.Open(path)
+	if err != nil {
+		return refs
+	}
+	defer f.Close()
+	var last string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "^"):
+			if last != "" {
+				refs[last] = strings.TrimPrefix(line, "^")
+			}
+		default:
+			if parts := strings.Fields(line); len(parts) == 2 {
+				refs[parts[1]] = parts[0]
+				last = parts[1]
+			}
+		}
+	}
+	return refs
+}
+
+func resolveRef(gitDir, ref string, packed map[string]string) string {
+	if contents, err := ioutil.ReadFile(filepath.Join(gitDir, filepath.FromSlash(ref))); err == nil {
+		return strings.TrimSpace(string(contents))
+	}
+	return packed[ref]
+}
+
+// tagFor returns the name of a tag pointing at commit, preferring loose tags over packed tags.
+// Loose annotated tags point at a tag object rather than the commit, so they are not found.
+func tagFor(gitDir, commit string, packed map[string]string) string {
+	tagsDir := filepath.Join(gitDir, "refs", "tags")
+	var found string
+	_ = filepath.Walk(tagsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || found != "" {
+			return nil
+		}
+		contents, err := ioutil.ReadFile(path)
+		if err == nil && strings.TrimSpace(string(contents)) == commit {
+			rel, _ := filepath.Rel(tagsDir, path)
+			found = filepath.ToSlash(rel)
+		}
+		return nil
+	})
+	if found != "" {
+		return found
+	}
+	var tags []string
+	for ref, sha := range packed {
+		if strings.HasPrefix(ref, "refs/tags/") && sha == commit {
+			tags = append(tags, strings.TrimPrefix(ref, "refs/tags/"))
+		}
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	// choose deterministically when several tags point at the commit
+	sort.Strings(tags)
+	return tags[0]
+}
+
+// originURL returns the URL of the 'origin' remote from the git config, with any credentials removed
+func originURL(configPath string) string {
+	f, err := os.Open(configPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	var inOrigin bool
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			inOrigin = line == `[remote "origin"]`
+			continue
+		}
+		if !inOrigin {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == "url" {
+			return withoutCredentials(strings.TrimSpace(parts[1]))
+		}
+	}
+	return ""
+}
+
+func withoutCredentials(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil || u.Scheme == "" {
+		// scp-like URLs, e.g. git@github.com:org/repo.git, only name the ssh user
+		return rawURL
+	}
+	u.User = nil
+	return u.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
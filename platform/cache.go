@@ -1,7 +1,20 @@
 package platform
 
+import "github.com/buildpacks/lifecycle/buildpack"
+
 type CacheMetadata struct {
 	Buildpacks []BuildpackLayersMetadata `json:"buildpacks"`
+	Detect     []DetectCacheEntry        `json:"detect,omitempty"`
+}
+
+// A DetectCacheEntry records the result of a buildpack's /bin/detect along with the key of the inputs that produced it.
+type DetectCacheEntry struct {
+	ID        string              `json:"id"`
+	Version   string              `json:"version"`
+	Key       string              `json:"key"`
+	BuildPlan buildpack.BuildPlan `json:"plan"`
+	Code      int                 `json:"code"`
+	Output    string              `json:"output,omitempty"`
 }
 
 func (cm *CacheMetadata) MetadataForBuildpack(id string) BuildpackLayersMetadata {
@@ -31,6 +31,7 @@ type LayersMetadata struct {
 	Config       LayerMetadata             `json:"config" toml:"config"`
 	Launcher     LayerMetadata             `json:"launcher" toml:"launcher"`
 	ProcessTypes LayerMetadata             `json:"process-types" toml:"process-types"`
+	SBOM         *LayerMetadata            `json:"sbom,omitempty" toml:"sbom,omitempty"`
 	RunImage     RunImageMetadata          `json:"runImage" toml:"run-image"`
 	Stack        StackMetadata             `json:"stack" toml:"stack"`
 }
@@ -44,6 +45,7 @@ type LayersMetadataCompat struct {
 	Config       LayerMetadata             `json:"config" toml:"config"`
 	Launcher     LayerMetadata             `json:"launcher" toml:"launcher"`
 	ProcessTypes LayerMetadata             `json:"process-types" toml:"process-types"`
+	SBOM         *LayerMetadata            `json:"sbom,omitempty" toml:"sbom,omitempty"`
 	RunImage     RunImageMetadata          `json:"runImage" toml:"run-image"`
 	Stack        StackMetadata             `json:"stack" toml:"stack"`
 }
@@ -78,6 +80,63 @@ type RunImageMetadata struct {
 	Reference string `json:"reference" toml:"reference"`
 }
 
+// build-report.toml
+
+type UsageReport struct {
+	Detect     []BuildpackUsage      `toml:"detect"`
+	Build      []BuildpackUsage      `toml:"build"`
+	AppChanges []BuildpackAppChanges `toml:"app-changes,omitempty"`
+}
+
+// BuildpackUsage records the time and memory used by a single /bin/detect or /bin/build invocation.
+type BuildpackUsage struct {
+	ID         string `toml:"id"`
+	Version    string `toml:"version"`
+	WallTimeMS int64  `toml:"wall-time-ms"`
+	UserTimeMS int64  `toml:"user-time-ms"`
+	SysTimeMS  int64  `toml:"sys-time-ms"`
+	MaxRSSKB   int64  `toml:"max-rss-kb"`
+}
+
+// BuildpackAppChanges records the paths in the app directory that were changed by a single /bin/build invocation.
+type BuildpackAppChanges struct {
+	ID       string   `toml:"id"`
+	Version  string   `toml:"version"`
+	Added    []string `toml:"added,omitempty"`
+	Removed  []string `toml:"removed,omitempty"`
+	Modified []string `toml:"modified,omitempty"`
+}
+
+// detect-report.json
+
+type DetectReport struct {
+	Groups []DetectGroupReport `json:"groups"`
+}
+
+type DetectGroupReport struct {
+	Buildpacks []DetectBuildpackReport `json:"buildpacks"`
+	Trials     []DetectTrialReport     `json:"trials,omitempty"`
+	Pass       bool                    `json:"pass"`
+	Error      string                  `json:"error,omitempty"`
+}
+
+type DetectBuildpackReport struct {
+	buildpack.GroupBuildpack
+	buildpack.BuildPlan
+	Result     string `toml:"result" json:"result"`
+	ExitCode   int    `toml:"exit-code" json:"exit-code"`
+	Output     string `toml:"output,omitempty" json:"output,omitempty"`
+	Error      string `toml:"error,omitempty" json:"error,omitempty"`
+	DurationMS int64  `toml:"duration-ms" json:"duration-ms"`
+}
+
+type DetectTrialReport struct {
+	Buildpacks []buildpack.GroupBuildpack `json:"buildpacks"`
+	Skipped    []string                   `json:"skipped,omitempty"`
+	Pass       bool                       `json:"pass"`
+	Error      string                     `json:"error,omitempty"`
+}
+
 // metadata.toml
 
 type BuildMetadata struct {
@@ -88,6 +147,14 @@ type BuildMetadata struct {
 	Processes                   []launch.Process           `toml:"processes" json:"processes"`
 	Slices                      []layers.Slice             `toml:"slices" json:"-"`
 	BuildpackDefaultProcessType string                     `toml:"buildpack-default-process-type,omitempty" json:"buildpack-default-process-type,omitempty"`
+	UnmetRequires               []UnmetRequire             `toml:"unmet-requires,omitempty" json:"-"`
+}
+
+// UnmetRequire is a build plan requirement that was left unmet after all buildpacks ran.
+type UnmetRequire struct {
+	Name      string                     `toml:"name"`
+	Providers []buildpack.GroupBuildpack `toml:"providers"`
+	UnmetBy   []buildpack.GroupBuildpack `toml:"unmet-by,omitempty"` // buildpacks that declared the requirement unmet in build.toml
 }
 
 type LauncherMetadata struct {
@@ -192,14 +259,34 @@ type ExportReport struct {
 }
 
 type BuildReport struct {
-	BOM []buildpack.BOMEntry `toml:"bom"`
+	BOM           []buildpack.BOMEntry `toml:"bom"`
+	UnmetRequires []UnmetRequire       `toml:"unmet-requires,omitempty"`
 }
 
 type ImageReport struct {
-	Tags         []string `toml:"tags"`
-	ImageID      string   `toml:"image-id,omitempty"`
-	Digest       string   `toml:"digest,omitempty"`
-	ManifestSize int64    `toml:"manifest-size,omitzero"`
+	Tags         []string      `toml:"tags"`
+	ImageID      string        `toml:"image-id,omitempty"`
+	Digest       string        `toml:"digest,omitempty"`
+	ManifestSize int64         `toml:"manifest-size,omitzero"`
+	Layers       []LayerReport `toml:"layers,omitempty"`
+}
+
+const (
+	LayerStatusAdded       = "added"
+	LayerStatusReused      = "reused"
+	LayerStatusLaunchCache = "launch-cache"
+)
+
+// LayerReport describes a layer of the exported image, in the order the layers were added.
+// Size is the uncompressed size of the layer, and is omitted when the layer was reused without being created locally.
+type LayerReport struct {
+	ID        string `toml:"id"`
+	Buildpack string `toml:"buildpack,omitempty"`
+	Layer     string `toml:"layer,omitempty"`
+	Slice     int    `toml:"slice,omitzero"`
+	DiffID    string `toml:"diff-id"`
+	Size      int64  `toml:"size,omitzero"`
+	Status    string `toml:"status"`
 }
 
 // stack.toml
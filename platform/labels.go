@@ -7,3 +7,12 @@ const (
 	StackIDLabel         = "io.buildpacks.stack.id"
 	MixinsLabel          = "io.buildpacks.stack.mixins"
 )
+
+// Annotations from the OCI image spec, set as labels on the app image
+const (
+	OCICreatedLabel  = "org.opencontainers.image.created"
+	OCIRevisionLabel = "org.opencontainers.image.revision"
+	OCISourceLabel   = "org.opencontainers.image.source"
+	OCITitleLabel    = "org.opencontainers.image.title"
+	OCIVersionLabel  = "org.opencontainers.image.version"
+)
@@ -4,9 +4,10 @@ import "github.com/buildpacks/lifecycle/cmd"
 
 var exitCodes = map[cmd.LifecycleExitError]int{
 	// detect phase errors: 100-199
-	cmd.FailedDetect:           100, // FailedDetect indicates that no buildpacks detected
-	cmd.FailedDetectWithErrors: 101, // FailedDetectWithErrors indicated that no buildpacks detected and at least one errored
-	cmd.DetectError:            102, // DetectError indicates generic detect error
+	cmd.FailedDetect:            100, // FailedDetect indicates that no buildpacks detected
+	cmd.FailedDetectWithErrors:  101, // FailedDetectWithErrors indicated that no buildpacks detected and at least one errored
+	cmd.DetectError:             102, // DetectError indicates generic detect error
+	cmd.FailedDetectWithTimeout: 103, // FailedDetectWithTimeout indicates that no buildpacks detected and at least one timed out
 
 	// analyze phase errors: 200-299
 	cmd.AnalyzeError: 202, // AnalyzeError indicates generic analyze error
@@ -15,8 +16,9 @@ var exitCodes = map[cmd.LifecycleExitError]int{
 	cmd.RestoreError: 302, // RestoreError indicates generic restore error
 
 	// build phase errors: 400-499
-	cmd.FailedBuildWithErrors: 401, // FailedBuildWithErrors indicates buildpack error during /bin/build
-	cmd.BuildError:            402, // BuildError indicates generic build error
+	cmd.FailedBuildWithErrors:  401, // FailedBuildWithErrors indicates buildpack error during /bin/build
+	cmd.BuildError:             402, // BuildError indicates generic build error
+	cmd.FailedBuildWithTimeout: 403, // FailedBuildWithTimeout indicates buildpack timeout during /bin/build
 
 	// export phase errors: 500-599
 	cmd.ExportError: 502, // ExportError indicates generic export error
@@ -4,9 +4,10 @@ import "github.com/buildpacks/lifecycle/cmd"
 
 var exitCodes = map[cmd.LifecycleExitError]int{
 	// detect phase errors: 20-29
-	cmd.FailedDetect:           20, // FailedDetect indicates that no buildpacks detected
-	cmd.FailedDetectWithErrors: 21, // FailedDetectWithErrors indicated that no buildpacks detected and at least one errored
-	cmd.DetectError:            22, // DetectError indicates generic detect error
+	cmd.FailedDetect:            20, // FailedDetect indicates that no buildpacks detected
+	cmd.FailedDetectWithErrors:  21, // FailedDetectWithErrors indicated that no buildpacks detected and at least one errored
+	cmd.DetectError:             22, // DetectError indicates generic detect error
+	cmd.FailedDetectWithTimeout: 23, // FailedDetectWithTimeout indicates that no buildpacks detected and at least one timed out
 
 	// analyze phase errors: 30-39
 	cmd.AnalyzeError: 32, // AnalyzeError indicates generic analyze error
@@ -15,8 +16,9 @@ var exitCodes = map[cmd.LifecycleExitError]int{
 	cmd.RestoreError: 42, // RestoreError indicates generic restore error
 
 	// build phase errors: 50-59
-	cmd.FailedBuildWithErrors: 51, // FailedBuildWithErrors indicates buildpack error during /bin/build
-	cmd.BuildError:            52, // BuildError indicates generic build error
+	cmd.FailedBuildWithErrors:  51, // FailedBuildWithErrors indicates buildpack error during /bin/build
+	cmd.BuildError:             52, // BuildError indicates generic build error
+	cmd.FailedBuildWithTimeout: 53, // FailedBuildWithTimeout indicates buildpack timeout during /bin/build
 
 	// export phase errors: 60-69
 	cmd.ExportError: 62, // ExportError indicates generic export error
@@ -0,0 +1,105 @@
+package sbom
+
+import (
+	"time"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+)
+
+const (
+	CycloneDXBuildpackIDProperty      = "io.buildpacks.buildpack.id"
+	CycloneDXBuildpackVersionProperty = "io.buildpacks.buildpack.version"
+)
+
+// CycloneDXDocument is a CycloneDX 1.3 BOM in its JSON serialization.
+type CycloneDXDocument struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Metadata    CycloneDXMetadata    `json:"metadata"`
+	Components  []CycloneDXComponent `json:"components"`
+}
+
+type CycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp"`
+	Tools     []CycloneDXTool     `json:"tools"`
+	Component *CycloneDXComponent `json:"component,omitempty"`
+}
+
+type CycloneDXTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type CycloneDXComponent struct {
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Type       string              `json:"type"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Hashes     []CycloneDXHash     `json:"hashes,omitempty"`
+	Licenses   []CycloneDXLicense  `json:"licenses,omitempty"`
+	Properties []CycloneDXProperty `json:"properties,omitempty"`
+}
+
+type CycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type CycloneDXLicense struct {
+	License CycloneDXLicenseChoice `json:"license"`
+}
+
+type CycloneDXLicenseChoice struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CycloneDX converts bom into a CycloneDX BOM describing the image called name.
+// The buildpack that contributed each component is recorded in its properties.
+func CycloneDX(bom []buildpack.BOMEntry, name, toolVersion string, created time.Time) CycloneDXDocument {
+	doc := CycloneDXDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.3",
+		Version:     1,
+		Metadata: CycloneDXMetadata{
+			Timestamp: created.UTC().Format(time.RFC3339),
+			Tools:     []CycloneDXTool{{Vendor: "Cloud Native Buildpacks", Name: "lifecycle", Version: toolVersion}},
+			Component: &CycloneDXComponent{Type: "container", Name: name},
+		},
+		Components: []CycloneDXComponent{},
+	}
+	for _, c := range components(bom) {
+		component := CycloneDXComponent{
+			Type:    "library",
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.PURL,
+		}
+		if c.SHA256 != "" {
+			component.Hashes = []CycloneDXHash{{Algorithm: "SHA-256", Content: c.SHA256}}
+		}
+		for _, license := range c.Licenses {
+			choice := CycloneDXLicenseChoice{Name: license}
+			if id, ok := licenseID(license); ok {
+				choice = CycloneDXLicenseChoice{ID: id}
+			}
+			component.Licenses = append(component.Licenses, CycloneDXLicense{License: choice})
+		}
+		if c.Buildpack.ID != "" {
+			component.Properties = []CycloneDXProperty{
+				{Name: CycloneDXBuildpackIDProperty, Value: c.Buildpack.ID},
+				{Name: CycloneDXBuildpackVersionProperty, Value: c.Buildpack.Version},
+			}
+		}
+		doc.Components = append(doc.Components, component)
+	}
+	return doc
+}
@@ -0,0 +1,109 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+)
+
+const (
+	SPDXFile      = "bom.spdx.json"
+	CycloneDXFile = "bom.cdx.json"
+)
+
+// Write writes SPDX and CycloneDX documents converted from bom to dir.
+func Write(dir string, bom []buildpack.BOMEntry, name, toolVersion string, created time.Time) error {
+	spdx, err := SPDX(bom, name, toolVersion, created)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := writeJSON(filepath.Join(dir, SPDXFile), spdx); err != nil {
+		return err
+	}
+	return writeJSON(filepath.Join(dir, CycloneDXFile), CycloneDX(bom, name, toolVersion, created))
+}
+
+func writeJSON(path string, data interface{}) error {
+	contents, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(contents, '\n'), 0644)
+}
+
+// component holds the details of a BOM entry that are common to SBOM formats.
+// Buildpacks conventionally record them in the entry metadata.
+type component struct {
+	Name      string
+	Version   string
+	PURL      string
+	URI       string
+	SHA256    string
+	Licenses  []string
+	Buildpack buildpack.GroupBuildpack
+}
+
+func components(bom []buildpack.BOMEntry) []component {
+	var out []component
+	for _, entry := range bom {
+		c := component{
+			Name:      entry.Name,
+			Version:   entry.Version,
+			PURL:      metadataString(entry.Metadata, "purl"),
+			URI:       metadataString(entry.Metadata, "uri"),
+			SHA256:    metadataString(entry.Metadata, "sha256"),
+			Licenses:  metadataLicenses(entry.Metadata),
+			Buildpack: entry.Buildpack.NoAPI().NoHomepage(),
+		}
+		if c.Version == "" {
+			c.Version = metadataString(entry.Metadata, "version")
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func metadataString(metadata map[string]interface{}, key string) string {
+	if v, ok := metadata[key]; ok {
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// metadataLicenses reads licenses recorded as a list of names, or as a list of tables with a type.
+func metadataLicenses(metadata map[string]interface{}) []string {
+	var out []string
+	add := func(license interface{}) {
+		switch license := license.(type) {
+		case string:
+			out = append(out, license)
+		case map[string]interface{}:
+			if t, ok := license["type"].(string); ok {
+				out = append(out, t)
+			}
+		}
+	}
+	switch licenses := metadata["licenses"].(type) {
+	case []interface{}:
+		for _, license := range licenses {
+			add(license)
+		}
+	case []map[string]interface{}:
+		for _, license := range licenses {
+			add(license)
+		}
+	case []string:
+		for _, license := range licenses {
+			add(license)
+		}
+	}
+	return out
+}
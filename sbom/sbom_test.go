@@ -0,0 +1,187 @@
+package sbom_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/sbom"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestSBOM(t *testing.T) {
+	spec.Run(t, "SBOM", testSBOM, spec.Report(report.Terminal{}))
+}
+
+func testSBOM(t *testing.T, when spec.G, it spec.S) {
+	var (
+		bom = []buildpack.BOMEntry{
+			{
+				Require: buildpack.Require{
+					Name: "openjdk",
+					Metadata: map[string]interface{}{
+						"version":  "11.0.11",
+						"purl":     "pkg:generic/openjdk@11.0.11",
+						"uri":      "https://example.com/openjdk.tgz",
+						"sha256":   "some-sha",
+						"licenses": []map[string]interface{}{{"type": "GPL-2.0-only"}, {"type": "Classpath exception"}},
+					},
+				},
+				Buildpack: buildpack.GroupBuildpack{ID: "A", Version: "v1", API: "0.6", Homepage: "some-homepage"},
+			},
+			{
+				Require:   buildpack.Require{Name: "maven", Version: "3.8.1", Metadata: map[string]interface{}{"licenses": []interface{}{"Apache-2.0"}}},
+				Buildpack: buildpack.GroupBuildpack{ID: "B", Version: "v2"},
+			},
+			{
+				Require:   buildpack.Require{Name: "jvmkill"},
+				Buildpack: buildpack.GroupBuildpack{ID: "A", Version: "v1"},
+			},
+		}
+		created = time.Date(1980, time.January, 1, 0, 0, 1, 0, time.UTC)
+	)
+
+	when("#SPDX", func() {
+		it("describes each entry as a package", func() {
+			doc, err := sbom.SPDX(bom, "some-image", "0.12.0", created)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, doc.SPDXVersion, "SPDX-2.2")
+			h.AssertEq(t, doc.Name, "some-image")
+			h.AssertEq(t, doc.CreationInfo, sbom.SPDXCreationInfo{
+				Created:  "1980-01-01T00:00:01Z",
+				Creators: []string{"Tool: lifecycle-0.12.0"},
+			})
+			h.AssertEq(t, doc.Packages[0], sbom.SPDXPackage{
+				SPDXID:           "SPDXRef-Package-1",
+				Name:             "openjdk",
+				VersionInfo:      "11.0.11",
+				DownloadLocation: "https://example.com/openjdk.tgz",
+				Checksums:        []sbom.SPDXChecksum{{Algorithm: "SHA256", ChecksumValue: "some-sha"}},
+				LicenseConcluded: "NOASSERTION",
+				LicenseDeclared:  "NOASSERTION", // "Classpath exception" is not a license identifier
+				CopyrightText:    "NOASSERTION",
+				ExternalRefs: []sbom.SPDXExternalRef{{
+					ReferenceCategory: "PACKAGE_MANAGER",
+					ReferenceType:     "purl",
+					ReferenceLocator:  "pkg:generic/openjdk@11.0.11",
+				}},
+				Comment: "Provided by buildpack A@v1",
+			})
+			h.AssertEq(t, doc.Packages[2].Name, "maven")
+			h.AssertEq(t, doc.Packages[2].VersionInfo, "3.8.1")
+			h.AssertEq(t, doc.Packages[2].LicenseDeclared, "Apache-2.0")
+		})
+
+		it("declares licenses that are not in the SPDX license list as NOASSERTION", func() {
+			doc, err := sbom.SPDX([]buildpack.BOMEntry{
+				{Require: buildpack.Require{Name: "some-dep", Metadata: map[string]interface{}{"licenses": []interface{}{"mit"}}}},
+				{Require: buildpack.Require{Name: "other-dep", Metadata: map[string]interface{}{"licenses": []interface{}{"MIT", "Proprietary"}}}},
+			}, "some-image", "0.12.0", created)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, doc.Packages[0].LicenseDeclared, "MIT")
+			h.AssertEq(t, doc.Packages[1].LicenseDeclared, "NOASSERTION")
+		})
+
+		it("attributes packages to the buildpacks that provided them", func() {
+			doc, err := sbom.SPDX(bom, "some-image", "0.12.0", created)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, len(doc.Packages), 5)
+			h.AssertEq(t, doc.Packages[1].SPDXID, "SPDXRef-Buildpack-1")
+			h.AssertEq(t, doc.Packages[1].Name, "A")
+			h.AssertEq(t, doc.Packages[3].SPDXID, "SPDXRef-Buildpack-2")
+			h.AssertEq(t, doc.Packages[3].Name, "B")
+			h.AssertEq(t, doc.Relationships, []sbom.SPDXRelationship{
+				{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Package-1"},
+				{SPDXElementID: "SPDXRef-Buildpack-1", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-Package-1"},
+				{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Package-2"},
+				{SPDXElementID: "SPDXRef-Buildpack-2", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-Package-2"},
+				{SPDXElementID: "SPDXRef-DOCUMENT", RelationshipType: "DESCRIBES", RelatedSPDXElement: "SPDXRef-Package-3"},
+				{SPDXElementID: "SPDXRef-Buildpack-1", RelationshipType: "CONTAINS", RelatedSPDXElement: "SPDXRef-Package-3"},
+			})
+		})
+
+		it("derives the document namespace from its contents", func() {
+			doc1, err := sbom.SPDX(bom, "some-image", "0.12.0", created)
+			h.AssertNil(t, err)
+			doc2, err := sbom.SPDX(bom, "some-image", "0.12.0", created)
+			h.AssertNil(t, err)
+			doc3, err := sbom.SPDX(bom[1:], "some-image", "0.12.0", created)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, doc1.DocumentNamespace, doc2.DocumentNamespace)
+			if doc1.DocumentNamespace == doc3.DocumentNamespace {
+				t.Fatalf("Expected documents with different contents to have different namespaces")
+			}
+		})
+	})
+
+	when("#CycloneDX", func() {
+		it("describes each entry as a component with buildpack properties", func() {
+			doc := sbom.CycloneDX(bom, "some-image", "0.12.0", created)
+
+			h.AssertEq(t, doc.BOMFormat, "CycloneDX")
+			h.AssertEq(t, doc.SpecVersion, "1.3")
+			h.AssertEq(t, doc.Metadata.Timestamp, "1980-01-01T00:00:01Z")
+			h.AssertEq(t, doc.Metadata.Component, &sbom.CycloneDXComponent{Type: "container", Name: "some-image"})
+			h.AssertEq(t, len(doc.Components), 3)
+			h.AssertEq(t, doc.Components[0], sbom.CycloneDXComponent{
+				Type:    "library",
+				Name:    "openjdk",
+				Version: "11.0.11",
+				PURL:    "pkg:generic/openjdk@11.0.11",
+				Hashes:  []sbom.CycloneDXHash{{Algorithm: "SHA-256", Content: "some-sha"}},
+				Licenses: []sbom.CycloneDXLicense{
+					{License: sbom.CycloneDXLicenseChoice{ID: "GPL-2.0-only"}},
+					{License: sbom.CycloneDXLicenseChoice{Name: "Classpath exception"}},
+				},
+				Properties: []sbom.CycloneDXProperty{
+					{Name: "io.buildpacks.buildpack.id", Value: "A"},
+					{Name: "io.buildpacks.buildpack.version", Value: "v1"},
+				},
+			})
+		})
+		it("names licenses that are not in the SPDX license list", func() {
+			doc := sbom.CycloneDX([]buildpack.BOMEntry{
+				{Require: buildpack.Require{Name: "some-dep", Metadata: map[string]interface{}{"licenses": []interface{}{"mit", "Proprietary"}}}},
+			}, "some-image", "0.12.0", created)
+
+			h.AssertEq(t, doc.Components[0].Licenses, []sbom.CycloneDXLicense{
+				{License: sbom.CycloneDXLicenseChoice{ID: "MIT"}},
+				{License: sbom.CycloneDXLicenseChoice{Name: "Proprietary"}},
+			})
+		})
+	})
+
+	when("#Write", func() {
+		it("writes both documents to the directory", func() {
+			tmpDir, err := ioutil.TempDir("", "lifecycle.sbom")
+			h.AssertNil(t, err)
+			defer os.RemoveAll(tmpDir)
+
+			dir := filepath.Join(tmpDir, "sbom")
+			h.AssertNil(t, sbom.Write(dir, bom, "some-image", "0.12.0", created))
+
+			var spdx sbom.SPDXDocument
+			contents, err := ioutil.ReadFile(filepath.Join(dir, "bom.spdx.json"))
+			h.AssertNil(t, err)
+			h.AssertNil(t, json.Unmarshal(contents, &spdx))
+			h.AssertEq(t, len(spdx.Packages), 5)
+
+			var cdx sbom.CycloneDXDocument
+			contents, err = ioutil.ReadFile(filepath.Join(dir, "bom.cdx.json"))
+			h.AssertNil(t, err)
+			h.AssertNil(t, json.Unmarshal(contents, &cdx))
+			h.AssertEq(t, len(cdx.Components), 3)
+		})
+	})
+}
@@ -0,0 +1,167 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+)
+
+const spdxNoAssertion = "NOASSERTION"
+
+// SPDXDocument is an SPDX 2.2 document in its JSON serialization.
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	Checksums        []SPDXChecksum    `json:"checksums,omitempty"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+	Comment          string            `json:"comment,omitempty"`
+}
+
+type SPDXChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// SPDX converts bom into an SPDX document describing the image called name.
+// Each buildpack that contributed to bom is a package that CONTAINS the packages it contributed.
+// The namespace of the document is derived from its contents, so that identical builds produce identical documents.
+func SPDX(bom []buildpack.BOMEntry, name, toolVersion string, created time.Time) (SPDXDocument, error) {
+	doc := SPDXDocument{
+		SPDXVersion: "SPDX-2.2",
+		DataLicense: "CC0-1.0",
+		SPDXID:      "SPDXRef-DOCUMENT",
+		Name:        name,
+		CreationInfo: SPDXCreationInfo{
+			Created:  created.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: lifecycle-" + toolVersion},
+		},
+		Packages:      []SPDXPackage{},
+		Relationships: []SPDXRelationship{},
+	}
+
+	bpIDs := map[string]string{}
+	for i, c := range components(bom) {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i+1)
+		doc.Packages = append(doc.Packages, spdxPackage(id, c))
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{
+			SPDXElementID:      doc.SPDXID,
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: id,
+		})
+		if c.Buildpack.ID == "" {
+			continue
+		}
+		bpID, ok := bpIDs[c.Buildpack.String()]
+		if !ok {
+			bpID = fmt.Sprintf("SPDXRef-Buildpack-%d", len(bpIDs)+1)
+			bpIDs[c.Buildpack.String()] = bpID
+			doc.Packages = append(doc.Packages, SPDXPackage{
+				SPDXID:           bpID,
+				Name:             c.Buildpack.ID,
+				VersionInfo:      c.Buildpack.Version,
+				DownloadLocation: spdxNoAssertion,
+				LicenseConcluded: spdxNoAssertion,
+				LicenseDeclared:  spdxNoAssertion,
+				CopyrightText:    spdxNoAssertion,
+				Comment:          "Cloud Native Buildpack",
+			})
+		}
+		doc.Relationships = append(doc.Relationships, SPDXRelationship{
+			SPDXElementID:      bpID,
+			RelationshipType:   "CONTAINS",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	contents, err := json.Marshal(doc)
+	if err != nil {
+		return SPDXDocument{}, err
+	}
+	sum := sha256.Sum256(contents)
+	doc.DocumentNamespace = "https://buildpacks.io/spdx/" + hex.EncodeToString(sum[:])
+	return doc, nil
+}
+
+func spdxPackage(id string, c component) SPDXPackage {
+	pkg := SPDXPackage{
+		SPDXID:           id,
+		Name:             c.Name,
+		VersionInfo:      c.Version,
+		DownloadLocation: spdxNoAssertion,
+		LicenseConcluded: spdxNoAssertion,
+		LicenseDeclared:  spdxLicense(c.Licenses),
+		CopyrightText:    spdxNoAssertion,
+	}
+	if c.URI != "" {
+		pkg.DownloadLocation = c.URI
+	}
+	if c.SHA256 != "" {
+		pkg.Checksums = []SPDXChecksum{{Algorithm: "SHA256", ChecksumValue: c.SHA256}}
+	}
+	if c.PURL != "" {
+		pkg.ExternalRefs = []SPDXExternalRef{{
+			ReferenceCategory: "PACKAGE_MANAGER",
+			ReferenceType:     "purl",
+			ReferenceLocator:  c.PURL,
+		}}
+	}
+	if c.Buildpack.ID != "" {
+		pkg.Comment = "Provided by buildpack " + c.Buildpack.String()
+	}
+	return pkg
+}
+
+// spdxLicense returns an SPDX license expression for licenses, if each is in the SPDX license list.
+func spdxLicense(licenses []string) string {
+	if len(licenses) == 0 {
+		return spdxNoAssertion
+	}
+	ids := make([]string, 0, len(licenses))
+	for _, license := range licenses {
+		id, ok := licenseID(license)
+		if !ok {
+			return spdxNoAssertion
+		}
+		ids = append(ids, id)
+	}
+	return strings.Join(ids, " AND ")
+}
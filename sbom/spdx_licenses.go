@@ -0,0 +1,86 @@
+package sbom
+
+import "strings"
+
+// spdxLicenseIDs are the identifiers of commonly used licenses in the SPDX license list, including deprecated ones.
+// Licenses that are not in this list are recorded as free-form names, rather than as identifiers.
+var spdxLicenseIDs = []string{
+	"0BSD",
+	"AFL-3.0",
+	"AGPL-3.0", "AGPL-3.0-only", "AGPL-3.0-or-later",
+	"Apache-1.0", "Apache-1.1", "Apache-2.0",
+	"APSL-2.0",
+	"Artistic-1.0", "Artistic-2.0",
+	"Beerware",
+	"BlueOak-1.0.0",
+	"BSD-1-Clause", "BSD-2-Clause", "BSD-2-Clause-Patent", "BSD-3-Clause", "BSD-3-Clause-Clear", "BSD-4-Clause",
+	"BSL-1.0",
+	"bzip2-1.0.6",
+	"CC-BY-3.0", "CC-BY-4.0", "CC-BY-SA-3.0", "CC-BY-SA-4.0", "CC0-1.0",
+	"CDDL-1.0", "CDDL-1.1",
+	"CECILL-2.1",
+	"CPAL-1.0",
+	"CPL-1.0",
+	"curl",
+	"ECL-2.0",
+	"EFL-2.0",
+	"EPL-1.0", "EPL-2.0",
+	"EUPL-1.1", "EUPL-1.2",
+	"GFDL-1.3", "GFDL-1.3-only", "GFDL-1.3-or-later",
+	"GPL-1.0", "GPL-1.0-only", "GPL-1.0-or-later",
+	"GPL-2.0", "GPL-2.0-only", "GPL-2.0-or-later",
+	"GPL-3.0", "GPL-3.0-only", "GPL-3.0-or-later",
+	"HPND",
+	"ICU",
+	"IJG",
+	"ImageMagick",
+	"IPL-1.0",
+	"ISC",
+	"JSON",
+	"LGPL-2.0", "LGPL-2.0-only", "LGPL-2.0-or-later",
+	"LGPL-2.1", "LGPL-2.1-only", "LGPL-2.1-or-later",
+	"LGPL-3.0", "LGPL-3.0-only", "LGPL-3.0-or-later",
+	"Libpng", "libpng-2.0",
+	"LPPL-1.3c",
+	"MIT", "MIT-0",
+	"MPL-1.1", "MPL-2.0", "MPL-2.0-no-copyleft-exception",
+	"MS-PL", "MS-RL",
+	"MulanPSL-2.0",
+	"NCSA",
+	"ODbL-1.0",
+	"OFL-1.1",
+	"OpenSSL",
+	"OSL-3.0",
+	"PHP-3.01",
+	"PostgreSQL",
+	"PSF-2.0",
+	"Python-2.0",
+	"Ruby",
+	"Sleepycat",
+	"SSPL-1.0",
+	"Unicode-DFS-2016",
+	"Unlicense",
+	"UPL-1.0",
+	"Vim",
+	"W3C",
+	"WTFPL",
+	"X11",
+	"Zlib", "zlib-acknowledgement",
+	"ZPL-2.0", "ZPL-2.1",
+}
+
+// spdxLicenseIDsByLower maps the lower case form of each SPDX license identifier to the identifier,
+// as identifiers are matched case-insensitively.
+var spdxLicenseIDsByLower = func() map[string]string {
+	m := make(map[string]string, len(spdxLicenseIDs))
+	for _, id := range spdxLicenseIDs {
+		m[strings.ToLower(id)] = id
+	}
+	return m
+}()
+
+// licenseID returns the SPDX license identifier of license, which is false if license is not in the SPDX license list.
+func licenseID(license string) (string, bool) {
+	id, ok := spdxLicenseIDsByLower[strings.ToLower(strings.TrimSpace(license))]
+	return id, ok
+}